@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// used when neither the configuration nor the repository provides a template
+const defaultBodyTemplate = "{ticket}\n\n{summary}"
+
+// trailers that link a commit to an issue
+var issueTrailerKeys = []string{"fixes", "closes", "resolves", "refs", "references", "related-to", "issue"}
+
+// locations of GitHub pull request templates, relative to the repository root
+var pullRequestTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+// body template from the configuration, else the repository's pull request
+// template with the generated summary on top, else the default
+func bodyTemplate(dir string, defaults PullRequestDefaults) string {
+	if defaults.BodyTemplate != "" {
+		return defaults.BodyTemplate
+	}
+	for _, path := range pullRequestTemplatePaths {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			continue
+		}
+		template := strings.TrimSpace(string(content))
+		if strings.Contains(template, "{summary}") || strings.Contains(template, "{commits}") {
+			return template
+		}
+		return defaultBodyTemplate + "\n\n" + template
+	}
+	return defaultBodyTemplate
+}
+
+// fill the template placeholders from the commits between base and HEAD
+func renderBody(dir string, template string, ticketID string, base string) string {
+	baseRef := baseRefIn(dir, base)
+	commits := commitSubjectsIn(dir, baseRef)
+	diffstat, _ := gitBackend.DiffStat(dir, baseRef)
+	issues := linkedIssuesIn(dir, baseRef)
+
+	sections := []string{}
+	if commits != "" {
+		sections = append(sections, "## Commits\n\n"+commits)
+	}
+	if diffstat != "" {
+		sections = append(sections, "## Changes\n\n"+diffstat)
+	}
+	if issues != "" {
+		sections = append(sections, "## Linked issues\n\n"+issues)
+	}
+
+	replacer := strings.NewReplacer(
+		"{ticket}", ticketID,
+		"{base}", base,
+		"{commits}", commits,
+		"{diffstat}", diffstat,
+		"{issues}", issues,
+		"{summary}", strings.Join(sections, "\n\n"),
+	)
+	return strings.TrimSpace(replacer.Replace(template))
+}
+
+// oldest first, as a markdown list
+func commitSubjectsIn(dir string, baseRef string) string {
+	subjects, err := gitBackend.CommitSubjects(dir, baseRef)
+	if err != nil || len(subjects) == 0 {
+		return ""
+	}
+	return "- " + strings.Join(subjects, "\n- ")
+}
+
+// issue references from commit trailers such as "Fixes: #12", as a markdown list
+func linkedIssuesIn(dir string, baseRef string) string {
+	trailers, err := gitBackend.CommitTrailers(dir, baseRef)
+	if err != nil {
+		return ""
+	}
+	issues := []string{}
+	for _, line := range trailers {
+		key, value, found := strings.Cut(line, ":")
+		if !found || getIndex(issueTrailerKeys, strings.ToLower(strings.TrimSpace(key))) == -1 {
+			continue
+		}
+		issue := strings.TrimSpace(key) + " " + strings.TrimSpace(value)
+		if getIndex(issues, issue) == -1 {
+			issues = append(issues, issue)
+		}
+	}
+	if len(issues) == 0 {
+		return ""
+	}
+	return "- " + strings.Join(issues, "\n- ")
+}
+
+// let the user revise text in $VISUAL or $EDITOR
+func editText(text string, name string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	file, err := os.CreateTemp("", "bsync-*-"+name)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text + "\n"); err != nil {
+		file.Close()
+		return "", err
+	}
+	file.Close()
+
+	// the editor may come with arguments, e.g. "code --wait"
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %s", err.Error())
+	}
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(edited)), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestRenderBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		repo     fakeRepository
+		template string
+		body     string
+	}{
+		{
+			name: "summary",
+			repo: fakeRepository{
+				Subjects: []string{"Add login form", "Validate email"},
+				Trailers: []string{"Fixes: #12", "Signed-off-by: dev", "Fixes: #12", "Refs: #7"},
+				DiffStat: "2 files changed, 10 insertions(+)",
+			},
+			template: defaultBodyTemplate,
+			body: "ABC-1\n\n## Commits\n\n- Add login form\n- Validate email\n\n" +
+				"## Changes\n\n2 files changed, 10 insertions(+)\n\n## Linked issues\n\n- Fixes #12\n- Refs #7",
+		},
+		{
+			name:     "no commits",
+			template: defaultBodyTemplate,
+			body:     "ABC-1",
+		},
+		{
+			name:     "placeholders",
+			repo:     fakeRepository{Subjects: []string{"Add login form"}},
+			template: "Into {base}:\n{commits}",
+			body:     "Into main:\n- Add login form",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := useFakeGit(t)
+			repo := test.repo
+			repo.RemoteBranches = []string{"main"}
+			fake.addRepository("/code/lib", &repo)
+
+			if body := renderBody("/code/lib", test.template, "ABC-1", "main"); body != test.body {
+				t.Errorf("got %q, want %q", body, test.body)
+			}
+		})
+	}
+}
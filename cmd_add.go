@@ -18,12 +18,38 @@ func addCmdAction(config Configuration) cli.ActionFunc {
 				repoExists = true
 			}
 		}
-		if repoExists {
-			fmt.Println("Repository\u001b[31;1m", currentRepositoryName, "\u001b[0malready exists")
+		dependsOn := cCtx.StringSlice("depends-on")
+		for _, dependency := range dependsOn {
+			if _, ok := config.Repositories[dependency]; !ok {
+				fmt.Println("Warning: dependency", dependency, "is not a registered repository")
+			}
+		}
+
+		if repoExists && (cCtx.IsSet("depends-on") || cCtx.IsSet("default-branch")) {
+			repo := config.Repositories[currentRepositoryName]
+			if cCtx.IsSet("depends-on") {
+				repo.DependsOn = dependsOn
+			}
+			if cCtx.IsSet("default-branch") {
+				repo.DefaultBranch = cCtx.String("default-branch")
+			}
+			config.Repositories[currentRepositoryName] = repo
+			if _, err := dependencyDepths(config, sortedRepositoryNames(config)); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			saveConfigToml(config)
+			fmt.Println("Updated repository", highlightStyle.Render(currentRepositoryName))
+		} else if repoExists {
+			fmt.Println("Repository", highlightStyle.Render(currentRepositoryName), "already exists")
 		} else {
 			newRepo := Repository{
-				Remote: remoteRepo,
-				Local:  localRepo,
+				Remote:        remoteRepo,
+				Local:         localRepo,
+				DependsOn:     dependsOn,
+				DefaultBranch: cCtx.String("default-branch"),
+			}
+			if newRepo.DefaultBranch == "" {
+				newRepo.DefaultBranch = defaultBranchIn(localRepo)
 			}
 
 			// if config.Repositories doesn't exist, create it
@@ -32,8 +58,11 @@ func addCmdAction(config Configuration) cli.ActionFunc {
 			}
 
 			config.Repositories[currentRepositoryName] = newRepo
+			if _, err := dependencyDepths(config, sortedRepositoryNames(config)); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
 			saveConfigToml(config)
-			fmt.Println("Added repository\u001b[31;1m", currentRepositoryName, "\u001b[0m")
+			fmt.Println("Added repository", highlightStyle.Render(currentRepositoryName))
 		}
 
 		return nil
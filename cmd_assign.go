@@ -12,6 +12,9 @@ func assignCmdAction(config Configuration) cli.ActionFunc {
 
 		// assign branch to trees
 		branch := getBranchName()
+		if err := enforceBranchPolicy(config.BranchPolicy, branch); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 		remoteRepository := getRemoteRepository()
 		repositoryName := parseRepositoryName(remoteRepository)
 
@@ -20,6 +23,9 @@ func assignCmdAction(config Configuration) cli.ActionFunc {
 			Repo:   repositoryName,
 		}
 
+		if err := checkTreeSortOrder(config.PickerSort); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 		p := tea.NewProgram(multiTreeAssignInitialModel(config, proposedState))
 		finalModel, err := p.StartReturningModel()
 		if err != nil {
@@ -30,7 +36,10 @@ func assignCmdAction(config Configuration) cli.ActionFunc {
 			if m.quit {
 				return nil
 			}
-			newTrees := makeNewTreesFromSelection(m, branch, repositoryName, config)
+			newTrees, err := makeNewTreesFromSelection(m, branch, repositoryName, config)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
 			config.Trees = newTrees
 			saveConfigToml(config)
 		}
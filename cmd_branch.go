@@ -8,9 +8,9 @@ import (
 
 func branchCmdAction(config Configuration) cli.ActionFunc {
 	return func(cCtx *cli.Context) error {
-		listBranches()
-
+		if err := listBranches(); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 		return nil
-
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+func branchNewCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		policy := config.BranchPolicy
+		types := policy.types()
+
+		// prompt for whatever wasn't given as a flag
+		branchType := cCtx.String("type")
+		if branchType == "" {
+			branchType = ask("Type ("+strings.Join(types, ", ")+")", types[0])
+		}
+		if getIndex(types, branchType) == -1 {
+			return cli.Exit(fmt.Sprintf("Unknown branch type %s, expected one of: %s", branchType, strings.Join(types, ", ")), 1)
+		}
+		ticket := cCtx.String("ticket")
+		if !cCtx.IsSet("ticket") && strings.Contains(policy.template(), "{ticket}") {
+			ticket = ask("Ticket", "")
+		}
+		description := cCtx.String("description")
+		if description == "" {
+			description = ask("Description", "")
+		}
+		if slugify(description) == "" {
+			return cli.Exit("A description is required", 1)
+		}
+
+		branch := generateBranchName(policy, branchType, ticket, description)
+		if err := enforceBranchPolicy(policy, branch); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if local, _ := branchExistsIn("", branch); local {
+			return cli.Exit("Branch "+branch+" already exists", 1)
+		}
+
+		// start from the default branch, as it is on origin when fetched
+		topLevel, err := gitBackend.TopLevel("")
+		if err != nil {
+			return cli.Exit("Not in a git repository", 1)
+		}
+		from := cCtx.String("from")
+		if from == "" {
+			if repoName := repositoryNameForPath(config, topLevel); repoName != "" {
+				from = baseRefIn(topLevel, repositoryDefaultBranch(config, repoName))
+			} else {
+				from = baseRefIn(topLevel, defaultBranchIn(topLevel))
+			}
+		}
+
+		if err := gitBackend.CreateBranch("", branch, from); err != nil {
+			return cli.Exit("Failed to create branch "+branch+": "+gitErrorMessage(err), 1)
+		}
+		fmt.Println(icon("ok"), "Created and switched to", branch, "from", from)
+		return nil
+	}
+}
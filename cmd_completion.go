@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"strings"
+)
+
+const bashCompletionScript = `# bsync bash completion, load with: source <(bsync completion bash)
+_bsync_completion() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" "${cur}" --generate-bash-completion 2>/dev/null )
+  else
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null )
+  fi
+  local IFS=$'\n'
+  COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
+  return 0
+}
+complete -o bashdefault -o default -F _bsync_completion bsync
+`
+
+const zshCompletionScript = `#compdef bsync
+# bsync zsh completion, load with: source <(bsync completion zsh)
+_bsync() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion 2>/dev/null)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    compadd -a opts
+  else
+    _files
+  fi
+}
+compdef _bsync bsync
+`
+
+const fishCompletionScript = `# bsync fish completion, load with: bsync completion fish | source
+function __bsync_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    if string match -q -- '-*' $current
+        $tokens $current --generate-bash-completion 2>/dev/null
+    else
+        $tokens --generate-bash-completion 2>/dev/null
+    end
+end
+complete -c bsync -f -a '(__bsync_complete)'
+`
+
+func completionCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		switch cCtx.Args().Get(0) {
+		case "bash":
+			fmt.Print(bashCompletionScript)
+		case "zsh":
+			fmt.Print(zshCompletionScript)
+		case "fish":
+			fmt.Print(fishCompletionScript)
+		default:
+			return cli.Exit("Please specify a shell: bash, zsh or fish", 1)
+		}
+		return nil
+	}
+}
+
+// complete values of flags taking trees, repositories or branches, and
+// positional arguments with candidates
+func completer(config Configuration, candidates func(Configuration) []string) cli.BashCompleteFunc {
+	return func(cCtx *cli.Context) {
+		previous := previousCompletionArg()
+		if strings.HasPrefix(previous, "-") && findFlag(cCtx.Command.Flags, strings.TrimLeft(previous, "-")) == nil {
+			cli.DefaultCompleteWithFlags(cCtx.Command)(cCtx)
+			return
+		}
+
+		values := []string{}
+		if flag := findFlag(cCtx.Command.Flags, strings.TrimLeft(previous, "-")); strings.HasPrefix(previous, "-") && flag != nil && flagTakesValue(flag) {
+			values = flagCandidates(config, flag)
+		} else if candidates != nil {
+			values = candidates(config)
+		}
+		for _, value := range values {
+			fmt.Println(value)
+		}
+	}
+}
+
+func flagTakesValue(flag cli.Flag) bool {
+	docFlag, ok := flag.(cli.DocGenerationFlag)
+	return ok && docFlag.TakesValue()
+}
+
+// candidates for a flag value, chosen by the placeholder in its usage,
+// e.g. "`tree` to run in"
+func flagCandidates(config Configuration, flag cli.Flag) []string {
+	docFlag, ok := flag.(cli.DocGenerationFlag)
+	if !ok {
+		return []string{}
+	}
+	_, rest, found := strings.Cut(docFlag.GetUsage(), "`")
+	placeholder, _, closed := strings.Cut(rest, "`")
+	if !found || !closed {
+		return []string{}
+	}
+	switch placeholder {
+	case "tree":
+		return sortedTreeNames(config)
+	case "repository":
+		return sortedRepositoryNames(config)
+	case "branch":
+		return localBranchNames()
+	}
+	return []string{}
+}
+
+// argument typed before the one being completed
+func previousCompletionArg() string {
+	args := os.Args
+	if len(args) > 0 && args[len(args)-1] == "--generate-bash-completion" {
+		args = args[:len(args)-1]
+	}
+	if len(args) < 2 {
+		return ""
+	}
+	return args[len(args)-1]
+}
+
+func treeCandidates(config Configuration) []string {
+	return sortedTreeNames(config)
+}
+
+func branchCandidates(config Configuration) []string {
+	return localBranchNames()
+}
+
+func shellCandidates(config Configuration) []string {
+	return []string{"bash", "zsh", "fish"}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+func copyCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		sourceName := cCtx.Args().Get(0)
+		destinationName := cCtx.Args().Get(1)
+		if sourceName == "" || destinationName == "" {
+			fmt.Fprintln(progressWriter(), "Please specify the tree to copy and the name of the copy")
+			return nil
+		}
+
+		source, ok := config.Trees[sourceName]
+		if !ok {
+			return cli.Exit("Tree "+sourceName+" does not exist", 1)
+		}
+		if _, exists := config.Trees[destinationName]; exists {
+			return cli.Exit("Tree "+destinationName+" already exists", 1)
+		}
+
+		owner, err := getGitUser()
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		// everything but name, owner and last use carries over; copy slices so
+		// the trees don't share a backing array
+		destination := source
+		destination.Name = destinationName
+		destination.Owner = owner
+		destination.LastUsed = 0
+		destination.States = append([]State{}, source.States...)
+		destination.MergeOrder = append([]string(nil), source.MergeOrder...)
+		config.Trees[destinationName] = destination
+
+		saveConfigToml(config)
+		fmt.Fprintln(progressWriter(), joinNonEmpty(icon("tree"), "Copied tree", sourceName, "to", destinationName))
+		return nil
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type doctorIssue struct {
+	subject string
+	problem string
+	fix     string
+	apply   func()
+}
+
+func doctorCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		searchRoots := cCtx.StringSlice("search")
+		if len(searchRoots) == 0 {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+			searchRoots = []string{homeDir}
+		}
+
+		issues := []doctorIssue{}
+		brokenRepos := map[string]bool{}
+
+		// validate repositories
+		for _, repoName := range sortedRepositoryNames(config) {
+			repoIssues := checkRepository(config, repoName, searchRoots, cCtx.Int("depth"))
+			if len(repoIssues) > 0 {
+				brokenRepos[repoName] = true
+				issues = append(issues, repoIssues...)
+			} else {
+				fmt.Fprintln(progressWriter(), icon("ok"), "repository", repoName)
+			}
+		}
+
+		// validate trees
+		for _, treeName := range sortedTreeNames(config) {
+			treeIssues := checkTree(config, treeName, brokenRepos)
+			if len(treeIssues) > 0 {
+				issues = append(issues, treeIssues...)
+			} else {
+				fmt.Fprintln(progressWriter(), icon("ok"), "tree", treeName)
+			}
+		}
+
+		// add rejects cycles, but the config may have been edited by hand
+		if _, err := dependencyDepths(config, sortedRepositoryNames(config)); err != nil {
+			issues = append(issues, doctorIssue{subject: "dependencies", problem: err.Error()})
+		}
+
+		if len(issues) == 0 {
+			fmt.Fprintln(progressWriter(), joinNonEmpty("\nNo problems found", icon("tree")))
+			return nil
+		}
+
+		fmt.Fprintln(progressWriter())
+		unresolved := 0
+		changed := false
+		for _, issue := range issues {
+			progressf("%s %s: %s\n", icon("fail"), issue.subject, issue.problem)
+			if issue.apply == nil {
+				unresolved++
+				continue
+			}
+			if cCtx.Bool("fix") || confirm("   fix: "+issue.fix+"?") {
+				issue.apply()
+				changed = true
+				fmt.Fprintln(progressWriter(), "   fixed:", issue.fix)
+			} else {
+				unresolved++
+			}
+		}
+
+		if changed {
+			saveConfigToml(config)
+		}
+		if unresolved > 0 {
+			return cli.Exit(fmt.Sprintf("\n%d problem(s) left unresolved", unresolved), 1)
+		}
+		return nil
+	}
+}
+
+func checkRepository(config Configuration, repoName string, searchRoots []string, depth int) []doctorIssue {
+	repo := config.Repositories[repoName]
+	subject := "repository " + repoName
+
+	relocate := func(problem string) []doctorIssue {
+		issue := doctorIssue{subject: subject, problem: problem}
+		if found := findClone(repo.Remote, searchRoots, depth); found != "" {
+			issue.fix = "relocate to " + found
+			issue.apply = func() {
+				repo.Local = found
+				config.Repositories[repoName] = repo
+			}
+		}
+		return []doctorIssue{issue}
+	}
+
+	if info, err := os.Stat(repo.Local); err != nil || !info.IsDir() {
+		return relocate("path " + repo.Local + " does not exist")
+	}
+	if !isGitRepository(repo.Local) {
+		return relocate(repo.Local + " is not the root of a git repository")
+	}
+	remote, err := getRemoteRepositoryIn(repo.Local)
+	if err != nil || remote == "" {
+		return relocate(repo.Local + " has no origin remote")
+	}
+	if !sameRemote(remote, repo.Remote) {
+		return relocate(fmt.Sprintf("origin of %s is %s, expected %s", repo.Local, remote, repo.Remote))
+	}
+	return nil
+}
+
+func checkTree(config Configuration, treeName string, brokenRepos map[string]bool) []doctorIssue {
+	tree := config.Trees[treeName]
+	subject := "tree " + treeName
+	issues := []doctorIssue{}
+
+	if tree.Name != treeName {
+		issues = append(issues, doctorIssue{
+			subject: subject,
+			problem: fmt.Sprintf("name field is %q", tree.Name),
+			fix:     "set name to " + treeName,
+			apply: func() {
+				tree := config.Trees[treeName]
+				tree.Name = treeName
+				config.Trees[treeName] = tree
+			},
+		})
+	}
+
+	for _, state := range tree.States {
+		state := state
+		removeState := func() {
+			tree := config.Trees[treeName]
+			for i, s := range tree.States {
+				if s == state {
+					tree.States = append(tree.States[:i:i], tree.States[i+1:]...)
+					break
+				}
+			}
+			config.Trees[treeName] = tree
+		}
+
+		repo, ok := config.Repositories[state.Repo]
+		if !ok {
+			issues = append(issues, doctorIssue{
+				subject: subject,
+				problem: "repository " + state.Repo + " is not registered",
+				fix:     "remove " + state.Repo + " (" + state.Branch + ") from tree",
+				apply:   removeState,
+			})
+			continue
+		}
+		if brokenRepos[state.Repo] {
+			continue
+		}
+		local, remote := branchExistsIn(repo.Local, state.Branch)
+		if !local && !remote {
+			issues = append(issues, doctorIssue{
+				subject: subject,
+				problem: "branch " + state.Branch + " does not exist in " + state.Repo,
+				fix:     "remove " + state.Repo + " (" + state.Branch + ") from tree",
+				apply:   removeState,
+			})
+		}
+	}
+	return issues
+}
+
+// search for a clone with the given remote below the search roots
+func findClone(remote string, searchRoots []string, depth int) string {
+	for _, root := range searchRoots {
+		found := ""
+		rootDepth := strings.Count(filepath.Clean(root), string(os.PathSeparator))
+		filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || found != "" {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+				if candidate, err := getRemoteRepositoryIn(path); err == nil && sameRemote(candidate, remote) {
+					found = path
+				}
+				return filepath.SkipDir
+			}
+			if strings.Count(path, string(os.PathSeparator))-rootDepth >= depth {
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if found != "" {
+			return found
+		}
+	}
+	return ""
+}
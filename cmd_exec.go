@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+func execCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		command := cCtx.Args().Slice()
+		if len(command) == 0 {
+			fmt.Println("Please specify a command to run, e.g. bsync exec -- make test")
+			return nil
+		}
+
+		treeName, err := resolveTreeName(config, cCtx.String("tree"))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		states, err := dependencyOrder(config, config.Trees[treeName].States)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		grouped := cCtx.Bool("group")
+
+		var mu sync.Mutex
+		errs, err := runInDependencyOrder(config, states, cCtx.Int("parallel"), cCtx.Bool("fail-fast"), func(ctx context.Context, _ int, state State) error {
+			repo, ok := config.Repositories[state.Repo]
+			if !ok {
+				return fmt.Errorf("repository %s is not registered", state.Repo)
+			}
+
+			var cmd *exec.Cmd
+			if cCtx.Bool("shell") {
+				cmd = exec.CommandContext(ctx, "sh", "-c", shellCommand(command))
+			} else {
+				cmd = exec.CommandContext(ctx, command[0], command[1:]...)
+			}
+			cmd.Dir = repo.Local
+
+			if grouped {
+				var out bytes.Buffer
+				cmd.Stdout = &out
+				cmd.Stderr = &out
+				err := cmd.Run()
+				mu.Lock()
+				fmt.Println(repoHeaderStyle.Render(state.Repo + " (" + state.Branch + ")"))
+				fmt.Print(out.String())
+				fmt.Println()
+				mu.Unlock()
+				return err
+			}
+
+			prefix := repoPrefixStyle.Render("["+state.Repo+"]") + " "
+			stdout := &prefixWriter{prefix: prefix, out: os.Stdout, mu: &mu}
+			stderr := &prefixWriter{prefix: prefix, out: os.Stderr, mu: &mu}
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
+			err := cmd.Run()
+			stdout.Flush()
+			stderr.Flush()
+			return err
+		})
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		// aggregate exit status
+		failed := 0
+		for i, err := range errs {
+			if err == nil {
+				continue
+			}
+			failed++
+			if err == context.Canceled {
+				fmt.Printf("%s %s\n", icon("skip"), states[i].Repo)
+			} else {
+				fmt.Printf("%s %s: %s\n", icon("fail"), states[i].Repo, err.Error())
+			}
+		}
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("%d of %d repositories failed", failed, len(states)), 1)
+		}
+		return nil
+	}
+}
+
+// a single argument is passed to the shell as written, several are quoted
+// so that each stays one word
+func shellCommand(args []string) string {
+	if len(args) == 1 {
+		return args[0]
+	}
+	quoted := []string{}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@%+,") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
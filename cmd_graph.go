@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+func graphCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		// whole graph, or only the repositories of a tree
+		states := []State{}
+		if len(args) > 0 {
+			tree, ok := config.Trees[args[0]]
+			if !ok {
+				return cli.Exit("Tree "+args[0]+" does not exist", 1)
+			}
+			states = tree.States
+		} else {
+			for _, repoName := range sortedRepositoryNames(config) {
+				states = append(states, State{Repo: repoName})
+			}
+		}
+
+		layers, err := dependencyLayers(config, states)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		included := map[string]bool{}
+		for _, state := range states {
+			included[state.Repo] = true
+		}
+		dependencies := func(repo string) []string {
+			deps := []string{}
+			for _, dependency := range config.Repositories[repo].DependsOn {
+				if included[dependency] {
+					deps = append(deps, dependency)
+				}
+			}
+			return deps
+		}
+
+		if cCtx.Bool("dot") {
+			fmt.Println("digraph bsync {")
+			for _, layer := range layers {
+				for _, state := range layer {
+					fmt.Printf("  %q;\n", state.Repo)
+					for _, dependency := range dependencies(state.Repo) {
+						fmt.Printf("  %q -> %q;\n", state.Repo, dependency)
+					}
+				}
+			}
+			fmt.Println("}")
+			return nil
+		}
+
+		for i, layer := range layers {
+			fmt.Println(highlightStyle.Render(fmt.Sprintf("layer %d", i+1)))
+			for _, state := range layer {
+				line := "⊢ " + state.Repo
+				if state.Branch != "" {
+					line += " (" + state.Branch + ")"
+				}
+				if deps := dependencies(state.Repo); len(deps) > 0 {
+					line += mutedStyle.Render(" → " + strings.Join(deps, ", "))
+				}
+				fmt.Println(line)
+			}
+		}
+		return nil
+	}
+}
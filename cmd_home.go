@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+func homeCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		// every registered repository, or those of the given tree
+		repoNames := sortedRepositoryNames(config)
+		treeName := append(args, "")[0]
+		if treeName != "" {
+			tree, ok := config.Trees[treeName]
+			if !ok {
+				return cli.Exit("Tree "+treeName+" does not exist", 1)
+			}
+			repoNames = []string{}
+			for _, state := range tree.States {
+				repoNames = append(repoNames, state.Repo)
+			}
+		}
+
+		states := defaultBranchStates(config, repoNames)
+		progressf("%s\n", joinNonEmpty(fmt.Sprintf("Returning %d repositories to their default branches", len(states)), icon("log")))
+
+		results := make([]stateResultJSON, len(states))
+		_, err = runInDependencyOrder(config, states, cCtx.Int("parallel"), false, func(ctx context.Context, i int, state State) error {
+			results[i] = homeState(config, state)
+			return nil
+		})
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		// no tree is loaded anymore once every repository is home
+		if treeName == "" && config.ActiveTree != "" {
+			config.ActiveTree = ""
+			saveConfigToml(config)
+		}
+		return printStateResults(results, "failed to return to the default branch")
+	}
+}
+
+// check out and fast-forward the default branch, leaving dirty repositories alone
+func homeState(config Configuration, state State) stateResultJSON {
+	result := checkoutDefaultBranch(config, state)
+	if result.Status == "failed" || result.Result == "uncommitted changes" {
+		return result
+	}
+	if err := pullBranchIn(config.Repositories[state.Repo].Local, state.Branch); err != nil {
+		return newStateResultJSON(state, result.Result, false, fmt.Errorf("pull failed: %s", gitErrorMessage(err)), 0, 0)
+	}
+	return newStateResultJSON(state, "pulled", false, nil, 0, 0)
+}
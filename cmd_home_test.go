@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHomeState(t *testing.T) {
+	tests := []struct {
+		name    string
+		repo    fakeRepository
+		status  string
+		outcome string
+		calls   []string
+	}{
+		{
+			name:    "feature branch",
+			repo:    fakeRepository{Current: "feature", Branches: []string{"main", "feature"}, RemoteBranches: []string{"main"}},
+			status:  "ok",
+			outcome: "pulled",
+			calls:   []string{"pull main"},
+		},
+		{
+			name:    "already home",
+			repo:    fakeRepository{Current: "main", Branches: []string{"main"}, RemoteBranches: []string{"main"}},
+			status:  "ok",
+			outcome: "pulled",
+			calls:   []string{"pull main"},
+		},
+		{
+			name:    "already home with uncommitted changes",
+			repo:    fakeRepository{Current: "main", Branches: []string{"main"}, RemoteBranches: []string{"main"}, Dirty: true},
+			status:  "skipped",
+			outcome: "uncommitted changes",
+		},
+		{
+			name:    "uncommitted changes",
+			repo:    fakeRepository{Current: "feature", Branches: []string{"main", "feature"}, RemoteBranches: []string{"main"}, Dirty: true},
+			status:  "skipped",
+			outcome: "uncommitted changes",
+		},
+		{
+			name:    "not on origin",
+			repo:    fakeRepository{Current: "feature", Branches: []string{"main", "feature"}},
+			status:  "failed",
+			outcome: "checked out",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := useFakeGit(t)
+			repo := test.repo
+			fake.addRepository("/code/lib", &repo)
+			config := Configuration{Repositories: map[string]Repository{"acme/lib": {Local: "/code/lib", DefaultBranch: "main"}}}
+
+			states := defaultBranchStates(config, []string{"acme/lib"})
+			result := homeState(config, states[0])
+			if result.Status != test.status || result.Result != test.outcome {
+				t.Errorf("got %s %q, want %s %q", result.Status, result.Result, test.status, test.outcome)
+			}
+			if !reflect.DeepEqual(repo.Calls, test.calls) {
+				t.Errorf("got calls %q, want %q", repo.Calls, test.calls)
+			}
+			if test.status != "skipped" && repo.Current != "main" {
+				t.Errorf("left %s checked out, want main", repo.Current)
+			}
+		})
+	}
+}
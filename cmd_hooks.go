@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const hookMarker = "# installed by bsync"
+
+const chainedHookSuffix = ".bsync-chained"
+
+const postCheckoutHookScript = `#!/bin/sh
+# installed by bsync
+hooks_dir=$(dirname "$0")
+if [ -x "$hooks_dir/post-checkout.bsync-chained" ]; then
+  "$hooks_dir/post-checkout.bsync-chained" "$@" || exit $?
+fi
+# ignore file checkouts and checkouts made by bsync itself
+[ "$3" = "1" ] || exit 0
+[ -z "$BSYNC_INTERNAL" ] || exit 0
+command -v bsync >/dev/null 2>&1 || exit 0
+bsync hooks post-checkout%s "$@"
+exit 0
+`
+
+func hooksInstallCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		mode := ""
+		if cCtx.Bool("auto") {
+			mode = " --auto"
+		}
+		script := fmt.Sprintf(postCheckoutHookScript, mode)
+
+		failed := 0
+		for _, repoName := range sortedRepositoryNames(config) {
+			if err := installHook(config.Repositories[repoName].Local, "post-checkout", script); err != nil {
+				failed++
+				fmt.Fprintln(progressWriter(), icon("fail"), repoName+":", err.Error())
+			} else {
+				fmt.Fprintln(progressWriter(), icon("ok"), repoName)
+			}
+		}
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("\nFailed to install hooks in %d repositories", failed), 1)
+		}
+		return nil
+	}
+}
+
+func hooksUninstallCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		failed := 0
+		for _, repoName := range sortedRepositoryNames(config) {
+			if err := uninstallHook(config.Repositories[repoName].Local, "post-checkout"); err != nil {
+				failed++
+				fmt.Fprintln(progressWriter(), icon("fail"), repoName+":", err.Error())
+			} else {
+				fmt.Fprintln(progressWriter(), icon("ok"), repoName)
+			}
+		}
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("\nFailed to remove hooks from %d repositories", failed), 1)
+		}
+		return nil
+	}
+}
+
+// called by the post-checkout hook after a branch checkout
+func hooksPostCheckoutCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		if os.Getenv(internalGitEnv) != "" {
+			return nil
+		}
+		tree, ok := config.Trees[config.ActiveTree]
+		if !ok {
+			return nil
+		}
+		topLevel, err := gitBackend.TopLevel("")
+		if err != nil {
+			return nil
+		}
+		repoName := repositoryNameForPath(config, topLevel)
+		i := getStateIndex(tree.States, repoName)
+		if repoName == "" || i == -1 {
+			return nil
+		}
+		branch, err := getBranchNameIn(topLevel)
+		if err != nil || branch == "HEAD" || branch == tree.States[i].Branch {
+			return nil
+		}
+		if err := checkBranchPolicy(config.BranchPolicy, branch); err != nil {
+			if config.BranchPolicy.Enforce == "refuse" {
+				fmt.Fprintln(progressWriter(), "bsync: not assigning to tree:", err.Error())
+				return nil
+			}
+			fmt.Fprintln(progressWriter(), "bsync: warning:", err.Error())
+		}
+
+		question := fmt.Sprintf("bsync: assign %s to tree %s for %s (was %s)?", branch, config.ActiveTree, repoName, tree.States[i].Branch)
+		if !cCtx.Bool("auto") && !confirmOnTerminal(question) {
+			return nil
+		}
+
+		// reload under the lock, the config may have changed while asking
+		assigned := false
+		withConfigLock(func() {
+			latest := loadConfigToml()
+			tree, ok := latest.Trees[config.ActiveTree]
+			i := getStateIndex(tree.States, repoName)
+			if !ok || i == -1 || latest.ActiveTree != config.ActiveTree {
+				return
+			}
+			tree.States[i].Branch = branch
+			latest.Trees[config.ActiveTree] = tree
+			writeConfigToml(latest)
+			assigned = true
+		})
+		if assigned {
+			progressf("bsync: tree %s now uses %s for %s\n", config.ActiveTree, branch, repoName)
+		}
+		return nil
+	}
+}
+
+func hooksDir(repoDir string) (string, error) {
+	dir, err := gitBackend.HooksDir(repoDir)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoDir, dir)
+	}
+	return dir, nil
+}
+
+func isBsyncHook(path string) bool {
+	content, err := os.ReadFile(path)
+	return err == nil && strings.Contains(string(content), hookMarker)
+}
+
+// write hook, moving an existing foreign hook aside so ours can chain it
+func installHook(repoDir string, name string, script string) error {
+	dir, err := hooksDir(repoDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	hookPath := filepath.Join(dir, name)
+	chainedPath := hookPath + chainedHookSuffix
+
+	if _, err := os.Stat(hookPath); err == nil && !isBsyncHook(hookPath) {
+		if _, err := os.Stat(chainedPath); err == nil {
+			return fmt.Errorf("both %s and %s exist", name, name+chainedHookSuffix)
+		}
+		if err := os.Rename(hookPath, chainedPath); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(hookPath, []byte(script), 0755)
+}
+
+// remove our hook and restore the one it chained
+func uninstallHook(repoDir string, name string) error {
+	dir, err := hooksDir(repoDir)
+	if err != nil {
+		return err
+	}
+	hookPath := filepath.Join(dir, name)
+	chainedPath := hookPath + chainedHookSuffix
+
+	if !isBsyncHook(hookPath) {
+		return nil
+	}
+	if err := os.Remove(hookPath); err != nil {
+		return err
+	}
+	if _, err := os.Stat(chainedPath); err == nil {
+		return os.Rename(chainedPath, hookPath)
+	}
+	return nil
+}
+
+// ask on the controlling terminal, since hooks don't get stdin
+func confirmOnTerminal(question string) bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		fmt.Fprintln(progressWriter(), question, "Run bsync assign to update the tree.")
+		return false
+	}
+	defer tty.Close()
+	fmt.Fprint(tty, question+" [y/N]: ")
+	answer, _ := bufio.NewReader(tty).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -7,7 +7,15 @@ import (
 
 func listCmdAction(config Configuration) cli.ActionFunc {
 	return func(cCtx *cli.Context) error {
-		for _, tree := range config.Trees {
+		if output.json {
+			trees := []Tree{}
+			for _, treeName := range sortedTreeNames(config) {
+				trees = append(trees, config.Trees[treeName])
+			}
+			return printJSON(trees)
+		}
+		for _, treeName := range sortedTreeNames(config) {
+			tree := config.Trees[treeName]
 			fmt.Println(tree.Name)
 			for _, state := range tree.States {
 				fmt.Println("⊢", state.Repo, "("+state.Branch+")")
@@ -13,7 +13,14 @@ func loadCmdAction(config Configuration) cli.ActionFunc {
 		if projectNameToLoad == "" {
 
 			// select project by ui
-			p := tea.NewProgram(singleSelectionInitialModel(config))
+			sortBy := cCtx.String("sort")
+			if sortBy == "" {
+				sortBy = config.PickerSort
+			}
+			if err := checkTreeSortOrder(sortBy); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			p := tea.NewProgram(singleSelectionInitialModel(config, sortBy))
 			finalModel, err := p.StartReturningModel()
 			if err != nil {
 				fmt.Println("Oh no, it broke!")
@@ -23,13 +30,22 @@ func loadCmdAction(config Configuration) cli.ActionFunc {
 				if m.quit {
 					return nil
 				}
-				fmt.Println("Loading tree:", m.choices[m.cursor])
-				projectNameToLoad = m.choices[m.cursor]
+				progressf("Loading tree: %s\n", m.current())
+				projectNameToLoad = m.current()
 			}
 		}
 		if projectNameToLoad != "" {
 			if _, ok := config.Trees[projectNameToLoad]; ok {
-				loadProject(projectNameToLoad, cCtx, config)
+				results, err := loadProject(projectNameToLoad, cCtx, config)
+				if err != nil {
+					return cli.Exit(err.Error(), 1)
+				}
+				config.ActiveTree = projectNameToLoad
+				touchTree(config, projectNameToLoad)
+				saveConfigToml(config)
+				if output.json {
+					return printJSON(treeResultsJSON{Tree: projectNameToLoad, Results: results})
+				}
 			} else {
 				fmt.Println("Project", projectNameToLoad, "does not exist")
 			}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+func mergeCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		intoName := cCtx.String("into")
+		if len(args) != 2 || intoName == "" {
+			fmt.Println("Please specify two trees to merge and a destination with --into")
+			return nil
+		}
+		firstName, secondName := args[0], args[1]
+
+		first, ok := config.Trees[firstName]
+		if !ok {
+			return cli.Exit("Tree "+firstName+" does not exist", 1)
+		}
+		second, ok := config.Trees[secondName]
+		if !ok {
+			return cli.Exit("Tree "+secondName+" does not exist", 1)
+		}
+		if _, exists := config.Trees[intoName]; exists && !cCtx.Bool("force") {
+			return cli.Exit("Tree "+intoName+" already exists (use --force to overwrite)", 1)
+		}
+
+		prefer := cCtx.String("prefer")
+		if prefer != "" && prefer != firstName && prefer != secondName {
+			return cli.Exit("--prefer must be "+firstName+" or "+secondName, 1)
+		}
+
+		// start from the first tree and fold in the second
+		states := []State{}
+		for _, state := range first.States {
+			if getStateIndex(states, state.Repo) == -1 {
+				states = append(states, state)
+			}
+		}
+		for _, state := range second.States {
+			i := getStateIndex(states, state.Repo)
+			if i == -1 {
+				states = append(states, state)
+				continue
+			}
+			if states[i].Branch == state.Branch {
+				continue
+			}
+
+			// both trees pin the repo to different branches
+			switch prefer {
+			case firstName:
+			case secondName:
+				states[i] = state
+			default:
+				fmt.Printf("Conflict in %s:\n  1) %s (%s)\n  2) %s (%s)\n", state.Repo, states[i].Branch, firstName, state.Branch, secondName)
+				for {
+					fmt.Print("Choose branch [1/2]: ")
+					answer, err := stdinReader.ReadString('\n')
+					answer = strings.TrimSpace(answer)
+					if answer == "1" {
+						break
+					}
+					if answer == "2" {
+						states[i] = state
+						break
+					}
+					if err != nil {
+						return cli.Exit("Merge aborted", 1)
+					}
+				}
+			}
+		}
+
+		owner, err := getGitUser()
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		config.Trees[intoName] = Tree{
+			Name:   intoName,
+			Owner:  owner,
+			States: states,
+		}
+
+		saveConfigToml(config)
+		fmt.Println(joinNonEmpty(icon("tree"), "Merged trees", firstName, "and", secondName, "into", intoName))
+		return nil
+	}
+}
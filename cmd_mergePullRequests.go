@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strconv"
+	"strings"
+)
+
+func mergePullRequestsCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if len(args) == 0 {
+			fmt.Println("Please specify a tree to merge pull requests for")
+			return nil
+		}
+		treeName := args[0]
+		tree, ok := config.Trees[treeName]
+		if !ok {
+			return cli.Exit("Tree "+treeName+" does not exist", 1)
+		}
+		method := cCtx.String("method")
+		if method != "merge" && method != "squash" && method != "rebase" {
+			return cli.Exit("--method must be merge, squash or rebase", 1)
+		}
+
+		order := tree.MergeOrder
+		if cCtx.IsSet("order") {
+			order = cCtx.StringSlice("order")
+		}
+		states, err := dependencyOrder(config, tree.States)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		states = orderStates(states, order)
+
+		// every pull request must be ready before any is merged
+		statuses := getPullRequestStatuses(config, states)
+		rows := [][]string{}
+		blocked := 0
+		for i, status := range statuses {
+			reasons := []string{}
+			switch {
+			case status.err != nil:
+				reasons = []string{status.err.Error()}
+			case !status.found:
+				reasons = []string{"no pull request"}
+			default:
+				reasons = status.pullRequest.mergeBlockers()
+			}
+			ready := icon("ok") + " ready"
+			if status.found && status.pullRequest.State == "MERGED" {
+				ready = icon("skip") + " already merged"
+			}
+			if len(reasons) > 0 {
+				blocked++
+				ready = icon("fail") + " " + strings.Join(reasons, ", ")
+			}
+			number := ""
+			if status.found {
+				number = "#" + strconv.Itoa(status.pullRequest.Number) + " → " + status.pullRequest.BaseRefName
+			}
+			rows = append(rows, []string{strconv.Itoa(i + 1), status.state.Repo, status.state.Branch, number, ready})
+		}
+		printTable([]string{"ORDER", "REPOSITORY", "BRANCH", "PR", "STATUS"}, rows)
+
+		if blocked > 0 {
+			return cli.Exit(fmt.Sprintf("\n%d of %d pull requests are not ready to merge", blocked, len(statuses)), 1)
+		}
+		if cCtx.Bool("dry-run") {
+			fmt.Println("\nDry run: all pull requests are ready, nothing was merged")
+			return nil
+		}
+
+		// merge in order, stopping at the first failure
+		toMerge := 0
+		for _, status := range statuses {
+			if status.pullRequest.State != "MERGED" {
+				toMerge++
+			}
+		}
+		fmt.Println()
+		merged := 0
+		for i, status := range statuses {
+			pr := status.pullRequest
+			if pr.State == "MERGED" {
+				fmt.Printf("%s %s #%d already merged into %s\n", icon("skip"), status.state.Repo, pr.Number, pr.BaseRefName)
+				continue
+			}
+			dir := config.Repositories[status.state.Repo].Local
+			if err := mergePullRequestIn(dir, pr.Number, method, cCtx.Bool("delete-branch")); err != nil {
+				fmt.Printf("%s %s #%d: %s\n", icon("fail"), status.state.Repo, pr.Number, err.Error())
+				for _, remaining := range statuses[i+1:] {
+					if remaining.pullRequest.State != "MERGED" {
+						fmt.Printf("%s %s #%d not merged\n", icon("skip"), remaining.state.Repo, remaining.pullRequest.Number)
+					}
+				}
+				return cli.Exit(fmt.Sprintf("\nStopped after merging %d of %d pull requests", merged, toMerge), 1)
+			}
+			merged++
+			fmt.Printf("%s %s #%d merged into %s\n", icon("ok"), status.state.Repo, pr.Number, pr.BaseRefName)
+		}
+		fmt.Println(joinNonEmpty("\nMerged all pull requests of tree", treeName, icon("tree")))
+		return nil
+	}
+}
+
+// states in the given repository order, unlisted repositories last
+func orderStates(states []State, order []string) []State {
+	ordered := []State{}
+	for _, repo := range order {
+		if i := getStateIndex(states, repo); i != -1 {
+			ordered = append(ordered, states[i])
+		}
+	}
+	for _, state := range states {
+		if getIndex(order, state.Repo) == -1 {
+			ordered = append(ordered, state)
+		}
+	}
+	return ordered
+}
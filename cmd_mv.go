@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+func moveCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		oldName := cCtx.Args().Get(0)
+		newName := cCtx.Args().Get(1)
+		if oldName == "" || newName == "" {
+			fmt.Fprintln(progressWriter(), "Please specify the tree to rename and its new name")
+			return nil
+		}
+
+		tree, ok := config.Trees[oldName]
+		if !ok {
+			return cli.Exit("Tree "+oldName+" does not exist", 1)
+		}
+		if _, exists := config.Trees[newName]; exists {
+			return cli.Exit("Tree "+newName+" already exists", 1)
+		}
+
+		// key and name must stay in sync
+		tree.Name = newName
+		delete(config.Trees, oldName)
+		config.Trees[newName] = tree
+		if config.ActiveTree == oldName {
+			config.ActiveTree = newName
+		}
+
+		saveConfigToml(config)
+		fmt.Fprintln(progressWriter(), joinNonEmpty(icon("tree"), "Renamed tree", oldName, "to", newName))
+		return nil
+	}
+}
@@ -12,13 +12,15 @@ func newCmdAction(config Configuration) cli.ActionFunc {
 	return func(cCtx *cli.Context) error {
 		// terminal input
 		reader := bufio.NewReader(os.Stdin)
-		fmt.Print(enterTextStyle.Render("Enter name for new tree: \u001b[31;1m"))
+		fmt.Print(enterTextStyle.Render("Enter name for new tree: "))
 		newTreeName, _ := reader.ReadString('\n')
-		fmt.Print("\u001b[0m")
-		fmt.Println(newTreeStyle.Render(fmt.Sprint("🌳 Created new tree: ", newTreeName)))
+		fmt.Println(newTreeStyle.Render(joinNonEmpty(icon("tree"), "Created new tree: "+newTreeName)))
 
 		newTreeName = strings.TrimSpace(newTreeName)
-		newTreeOwner := getGitUser()
+		newTreeOwner, err := getGitUser()
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 
 		// create new tree
 		newTree := Tree{
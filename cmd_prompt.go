@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prints the active tree for shell prompts; reads only the config and
+// .git/HEAD so it never spawns git or touches the network. Nothing is
+// cached: a cache file would be read and parsed on every prompt just like
+// the config it mirrors.
+func promptCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		if config.ActiveTree == "" {
+			return nil
+		}
+		tree, ok := config.Trees[config.ActiveTree]
+		if !ok {
+			return nil
+		}
+
+		values := map[string]string{
+			"tree":    config.ActiveTree,
+			"repo":    "",
+			"branch":  "",
+			"current": "",
+			"status":  "",
+		}
+
+		cwd, err := os.Getwd()
+		if err == nil {
+			if topLevel, current, ok := readGitHead(cwd); ok {
+				values["current"] = current
+				repoName := repositoryNameForPath(config, topLevel)
+				values["repo"] = repoName
+				if i := getStateIndex(tree.States, repoName); repoName != "" && i != -1 {
+					expected := tree.States[i].Branch
+					values["branch"] = expected
+					values["status"] = "✓"
+					if current != expected {
+						values["status"] = "≠" + expected
+					}
+				}
+			}
+		}
+
+		prompt := cCtx.String("format")
+		for key, value := range values {
+			prompt = strings.ReplaceAll(prompt, "{"+key+"}", value)
+		}
+		fmt.Print(strings.TrimSpace(prompt))
+		return nil
+	}
+}
+
+// find the repository containing dir and its checked out branch
+func readGitHead(dir string) (topLevel string, branch string, ok bool) {
+	for {
+		gitPath := filepath.Join(dir, ".git")
+		info, err := os.Stat(gitPath)
+		if err == nil {
+			gitDir := gitPath
+			if !info.IsDir() {
+				// worktrees and submodules point to the real git dir
+				content, err := os.ReadFile(gitPath)
+				if err != nil {
+					return "", "", false
+				}
+				gitDir = strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+				if !filepath.IsAbs(gitDir) {
+					gitDir = filepath.Join(dir, gitDir)
+				}
+			}
+			head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return "", "", false
+			}
+			ref := strings.TrimSpace(string(head))
+			if strings.HasPrefix(ref, "ref: refs/heads/") {
+				return dir, strings.TrimPrefix(ref, "ref: refs/heads/"), true
+			}
+			// detached head
+			if len(ref) > 7 {
+				ref = ref[:7]
+			}
+			return dir, ref, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
+
+// registered repository whose local path is dir
+func repositoryNameForPath(config Configuration, dir string) string {
+	for name, repo := range config.Repositories {
+		if filepath.Clean(repo.Local) == filepath.Clean(dir) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strconv"
+	"time"
+)
+
+type pullRequestStatus struct {
+	state       State
+	pullRequest pullRequestInfo
+	found       bool
+	err         error
+}
+
+type pullRequestStatusJSON struct {
+	Repo      string `json:"repo"`
+	Branch    string `json:"branch"`
+	Number    int    `json:"number,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Base      string `json:"base,omitempty"`
+	State     string `json:"state"`
+	Review    string `json:"review,omitempty"`
+	Checks    string `json:"checks,omitempty"`
+	Mergeable string `json:"mergeable,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func prsCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		treeName, err := resolveTreeName(config, append(args, "")[0])
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		states, err := dependencyOrder(config, config.Trees[treeName].States)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		for {
+			statuses := getPullRequestStatuses(config, states)
+			if output.json {
+				printPullRequestStatusesJSON(statuses)
+			} else {
+				if cCtx.Bool("watch") {
+					clearScreen()
+				}
+				printPullRequestStatuses(treeName, statuses)
+			}
+			if !cCtx.Bool("watch") {
+				return nil
+			}
+			if !output.json {
+				fmt.Println(mutedStyle.Render("\nRefreshing every " + cCtx.Duration("interval").String() + ", ctrl+c to quit"))
+			}
+			time.Sleep(cCtx.Duration("interval"))
+		}
+	}
+}
+
+func getPullRequestStatuses(config Configuration, states []State) []pullRequestStatus {
+	statuses := make([]pullRequestStatus, len(states))
+	runAcrossStates(states, 4, false, func(ctx context.Context, i int, state State) error {
+		statuses[i].state = state
+		repo, ok := config.Repositories[state.Repo]
+		if !ok {
+			statuses[i].err = fmt.Errorf("repository is not registered")
+			return nil
+		}
+		pullRequests, err := listPullRequestsIn(repo.Local, state.Branch)
+		if err != nil {
+			statuses[i].err = err
+			return nil
+		}
+		statuses[i].pullRequest, statuses[i].found = currentPullRequest(pullRequests)
+		return nil
+	})
+	return statuses
+}
+
+func printPullRequestStatuses(treeName string, statuses []pullRequestStatus) {
+	fmt.Println(highlightStyle.Render(treeName))
+	rows := [][]string{}
+	for _, status := range statuses {
+		row := []string{status.state.Repo, status.state.Branch}
+		switch {
+		case status.err != nil:
+			row = append(row, icon("fail")+" "+status.err.Error(), "", "", "", "")
+		case !status.found:
+			row = append(row, "no pull request", "", "", "", "")
+		default:
+			pr := status.pullRequest
+			row = append(row,
+				"#"+strconv.Itoa(pr.Number)+" → "+pr.BaseRefName,
+				pr.displayState(),
+				pr.displayReview(),
+				pr.checksState(),
+				pr.displayMergeable(),
+			)
+		}
+		rows = append(rows, row)
+	}
+	printTable([]string{"REPOSITORY", "BRANCH", "PR", "STATE", "REVIEW", "CHECKS", "MERGEABLE"}, rows)
+}
+
+func printPullRequestStatusesJSON(statuses []pullRequestStatus) {
+	results := []pullRequestStatusJSON{}
+	for _, status := range statuses {
+		result := pullRequestStatusJSON{Repo: status.state.Repo, Branch: status.state.Branch, State: "none"}
+		if status.err != nil {
+			result.State = "error"
+			result.Error = status.err.Error()
+		} else if status.found {
+			pr := status.pullRequest
+			result.Number = pr.Number
+			result.URL = pr.URL
+			result.Base = pr.BaseRefName
+			result.State = pr.displayState()
+			result.Review = pr.displayReview()
+			result.Checks = pr.checksState()
+			result.Mergeable = pr.displayMergeable()
+		}
+		results = append(results, result)
+	}
+	printJSON(results)
+}
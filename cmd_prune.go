@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"time"
+)
+
+type pruneCandidate struct {
+	Repo    string   `json:"repo"`
+	Branch  string   `json:"branch"`
+	Reasons []string `json:"reasons"`
+	Trees   []string `json:"trees,omitempty"`
+	Result  string   `json:"result"`
+	Error   string   `json:"error,omitempty"`
+	merged  bool
+	baseRef string
+}
+
+func pruneCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		olderThan := cCtx.Int("older-than")
+
+		// find stale branches per repository
+		candidates := []pruneCandidate{}
+		for _, repoName := range sortedRepositoryNames(config) {
+			repoCandidates, err := findStaleBranches(config, repoName, olderThan)
+			if err != nil {
+				fmt.Fprintln(progressWriter(), icon("fail"), repoName+":", err.Error())
+				continue
+			}
+			candidates = append(candidates, repoCandidates...)
+		}
+		if len(candidates) == 0 {
+			progressf("%s\n", joinNonEmpty("No stale branches found", icon("tree")))
+			if output.json {
+				return printJSON(candidates)
+			}
+			return nil
+		}
+
+		// show candidates grouped per repository
+		lastRepo := ""
+		for _, candidate := range candidates {
+			if candidate.Repo != lastRepo {
+				progressf("\n%s\n", highlightStyle.Render(candidate.Repo))
+				lastRepo = candidate.Repo
+			}
+			used := ""
+			if len(candidate.Trees) > 0 {
+				used = " (used by " + strings.Join(candidate.Trees, ", ") + ")"
+			}
+			progressf("  %s  %s%s\n", candidate.Branch, mutedStyle.Render(strings.Join(candidate.Reasons, ", ")), used)
+		}
+		progressf("\n")
+
+		// delete the selected branches
+		failed := 0
+		for i, candidate := range candidates {
+			candidates[i].Result = "kept"
+			if cCtx.Bool("dry-run") {
+				continue
+			}
+			if len(candidate.Trees) > 0 && !cCtx.Bool("force") {
+				candidates[i].Result = "in use"
+				continue
+			}
+			if !cCtx.Bool("yes") && !confirm(fmt.Sprintf("Delete %s in %s?", candidate.Branch, candidate.Repo)) {
+				continue
+			}
+			if err := deleteStaleBranch(config.Repositories[candidate.Repo].Local, candidate); err != nil {
+				failed++
+				candidates[i].Result = "failed"
+				candidates[i].Error = err.Error()
+				fmt.Fprintln(progressWriter(), icon("fail"), candidate.Repo, candidate.Branch+":", candidates[i].Error)
+				continue
+			}
+			candidates[i].Result = "deleted"
+			fmt.Fprintln(progressWriter(), icon("ok"), "Deleted", candidate.Branch, "in", candidate.Repo)
+		}
+
+		if output.json {
+			printJSON(candidates)
+		} else if !cCtx.Bool("dry-run") {
+			inUse := 0
+			for _, candidate := range candidates {
+				if candidate.Result == "in use" {
+					inUse++
+				}
+			}
+			if inUse > 0 {
+				fmt.Printf("\nKept %d branch(es) used by trees, use --force to delete them\n", inUse)
+			}
+		}
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("\nFailed to delete %d branch(es)", failed), 1)
+		}
+		return nil
+	}
+}
+
+// local branches merged into the default branch, with a gone upstream, or
+// without commits for olderThan days. The checked out and default branches
+// are never candidates.
+func findStaleBranches(config Configuration, repoName string, olderThan int) ([]pruneCandidate, error) {
+	dir := config.Repositories[repoName].Local
+	defaultBranch := repositoryDefaultBranch(config, repoName)
+	current, err := getBranchNameIn(dir)
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository")
+	}
+
+	baseRef := baseRefIn(dir, defaultBranch)
+	merged := map[string]bool{}
+	if branches, err := gitBackend.MergedBranches(dir, baseRef); err == nil {
+		for _, branch := range branches {
+			merged[branch] = true
+		}
+	}
+	baseHistory := firstParentHistoryIn(dir, baseRef)
+
+	details, err := gitBackend.BranchDetails(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s", gitErrorMessage(err))
+	}
+	cutoff := time.Now().AddDate(0, 0, -olderThan).Unix()
+	candidates := []pruneCandidate{}
+	for _, detail := range details {
+		if detail.Name == current || detail.Name == defaultBranch {
+			continue
+		}
+		branch := detail.Name
+		stale := olderThan > 0 && detail.Committed < cutoff
+
+		// a branch just created from the default branch is merged too,
+		// but nothing was done on it yet
+		isMerged := merged[branch] && (stale || hasOwnCommitsIn(dir, branch, baseHistory))
+		reasons := []string{}
+		if isMerged {
+			reasons = append(reasons, "merged into "+defaultBranch)
+		}
+		if detail.UpstreamGone {
+			reasons = append(reasons, "upstream gone")
+		}
+		if stale {
+			reasons = append(reasons, fmt.Sprintf("no commits for %d days", int(time.Since(time.Unix(detail.Committed, 0)).Hours()/24)))
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		candidates = append(candidates, pruneCandidate{
+			Repo:    repoName,
+			Branch:  branch,
+			Reasons: reasons,
+			Trees:   treesUsingBranch(config, repoName, branch),
+			merged:  isMerged,
+			baseRef: baseRef,
+		})
+	}
+	return candidates, nil
+}
+
+// commits on the first-parent history of ref, the commits a branch can
+// be created from without adding its own
+func firstParentHistoryIn(dir string, ref string) map[string]bool {
+	history := map[string]bool{}
+	if commits, err := gitBackend.FirstParentHistory(dir, ref); err == nil {
+		for _, commit := range commits {
+			history[commit] = true
+		}
+	}
+	return history
+}
+
+// whether commits were made on the branch: its tip was merged in from
+// outside the base history, or its reflog shows more than its creation
+func hasOwnCommitsIn(dir string, branch string, baseHistory map[string]bool) bool {
+	if tip, err := gitBackend.ResolveCommit(dir, "refs/heads/"+branch); err == nil && !baseHistory[tip] {
+		return true
+	}
+	entries, err := gitBackend.Reflog(dir, branch)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry != "" && !strings.HasPrefix(entry, "branch: Created from") {
+			return true
+		}
+	}
+	return false
+}
+
+// delete a branch; a merged branch is checked against the same base it was
+// found merged into, not against HEAD or its upstream as git branch -d does
+func deleteStaleBranch(dir string, candidate pruneCandidate) error {
+	if candidate.merged {
+		if !gitBackend.IsAncestor(dir, "refs/heads/"+candidate.Branch, candidate.baseRef) {
+			return fmt.Errorf("no longer merged into %s", candidate.baseRef)
+		}
+	}
+	if err := gitBackend.DeleteBranch(dir, candidate.Branch); err != nil {
+		return fmt.Errorf("%s", gitErrorMessage(err))
+	}
+	return nil
+}
+
+func treesUsingBranch(config Configuration, repoName string, branch string) []string {
+	trees := []string{}
+	for _, treeName := range sortedTreeNames(config) {
+		for _, state := range config.Trees[treeName].States {
+			if state.Repo == repoName && state.Branch == branch {
+				trees = append(trees, treeName)
+				break
+			}
+		}
+	}
+	return trees
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindStaleBranches(t *testing.T) {
+	fake := useFakeGit(t)
+	old := time.Now().Add(-60*24*time.Hour - time.Hour).Unix()
+	recent := time.Now().Unix()
+	fake.addRepository("/code/lib", &fakeRepository{
+		Current:        "feature",
+		Branches:       []string{"main", "feature", "fresh", "done", "merged-in", "gone", "old", "fresh-old"},
+		RemoteBranches: []string{"main"},
+		Merged:         []string{"main", "feature", "fresh", "done", "merged-in", "fresh-old"},
+		Gone:           []string{"gone"},
+		Committed: map[string]int64{
+			"main": recent, "feature": recent, "fresh": recent, "done": recent,
+			"merged-in": recent, "gone": recent, "old": old, "fresh-old": old,
+		},
+		Commits: map[string]string{
+			"refs/heads/fresh": "c1", "refs/heads/done": "c2", "refs/heads/merged-in": "c9", "refs/heads/fresh-old": "c1",
+		},
+		History: map[string][]string{"origin/main": {"c2", "c1"}},
+		Reflogs: map[string][]string{
+			"fresh":     {"branch: Created from origin/main"},
+			"done":      {"commit: finish", "branch: Created from origin/main"},
+			"fresh-old": {"branch: Created from origin/main"},
+		},
+	})
+	config := Configuration{
+		Repositories: map[string]Repository{"acme/lib": {Local: "/code/lib", DefaultBranch: "main"}},
+		Trees:        map[string]Tree{"t1": {Name: "t1", States: []State{{Repo: "acme/lib", Branch: "gone"}}}},
+	}
+
+	candidates, err := findStaleBranches(config, "acme/lib", 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := map[string][]string{}
+	for _, candidate := range candidates {
+		got[candidate.Branch] = candidate.Reasons
+		if candidate.baseRef != "origin/main" {
+			t.Errorf("%s: got base %s, want origin/main", candidate.Branch, candidate.baseRef)
+		}
+	}
+	want := map[string][]string{
+		"done":      {"merged into main"},
+		"merged-in": {"merged into main"},
+		"gone":      {"upstream gone"},
+		"old":       {"no commits for 60 days"},
+		"fresh-old": {"merged into main", "no commits for 60 days"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, candidate := range candidates {
+		if candidate.Branch == "gone" && !reflect.DeepEqual(candidate.Trees, []string{"t1"}) {
+			t.Errorf("gone: got trees %v, want [t1]", candidate.Trees)
+		}
+	}
+}
+
+func TestDeleteStaleBranch(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate pruneCandidate
+		err       string
+		calls     []string
+	}{
+		{
+			name:      "merged",
+			candidate: pruneCandidate{Branch: "done", merged: true, baseRef: "origin/main"},
+			calls:     []string{"delete done"},
+		},
+		{
+			name:      "no longer merged",
+			candidate: pruneCandidate{Branch: "feature", merged: true, baseRef: "origin/main"},
+			err:       "no longer merged into origin/main",
+		},
+		{
+			name:      "not merged",
+			candidate: pruneCandidate{Branch: "feature", baseRef: "origin/main"},
+			calls:     []string{"delete feature"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := useFakeGit(t)
+			repo := &fakeRepository{Branches: []string{"main", "done", "feature"}, Merged: []string{"main", "done"}}
+			fake.addRepository("/code/lib", repo)
+
+			err := deleteStaleBranch("/code/lib", test.candidate)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(repo.Calls, test.calls) {
+				t.Errorf("got calls %q, want %q", repo.Calls, test.calls)
+			}
+		})
+	}
+}
@@ -4,41 +4,235 @@ import (
 	"fmt"
 	"github.com/urfave/cli/v2"
 	"strings"
-	"bufio"
 	"os"
+	"strconv"
 )
 
 func pullRequestCmdAction(config Configuration) cli.ActionFunc {
 	return func(cCtx *cli.Context) error {
-		firstArg := cCtx.Args().Get(0)
-		pullBranches := cCtx.Args().Tail()
+		pullBranches, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 
-		if firstArg != "" {
-			pullBranches = append([]string{firstArg}, pullBranches...)
+		if cCtx.Bool("update") && cCtx.Bool("skip-existing") {
+			return cli.Exit("--update and --skip-existing can't be used together", 1)
 		}
 
+		branchName := getBranchName()
+		localRepo := getLocalRepository()
+		repoName := repositoryNameForPath(config, localRepo)
+		defaults := config.Repositories[repoName].PullRequests
+
+		// default to the repository's default branch
 		if len(pullBranches) == 0 {
-			fmt.Println("Please specify a branch to open a pull request for")
-			return nil
+			if repoName != "" {
+				pullBranches = []string{repositoryDefaultBranch(config, repoName)}
+			} else {
+				pullBranches = []string{defaultBranchIn(localRepo)}
+			}
 		}
 
-		branchName := getBranchName()
+		// derive titles up front so broken title rules fail before anything is pushed
+		titles := map[string]string{}
+		for _, destinationBranch := range pullBranches {
+			title, err := formatPRTitle(config, branchName, destinationBranch)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+			titles[destinationBranch] = title
+		}
+		if cCtx.Bool("preview") {
+			return previewTitles(branchName, pullBranches, titles)
+		}
+
+		// gh prompts when the branch isn't pushed, so push it first
+		if err := ensureBranchPushed(cCtx, localRepo, branchName); err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
 
 		// prompt user to input Monday ticket id (if applicable)
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("Enter Monday ticket ID (default: #): ")
-		ticketID, _ := reader.ReadString('\n')
+		fmt.Fprint(progressWriter(), "Enter Monday ticket ID (default: #): ")
+		ticketID, _ := stdinReader.ReadString('\n')
 		ticketID = strings.TrimSpace(ticketID) // remove the newline
 		if !strings.HasPrefix(ticketID, "#") {
 			ticketID = "#" + ticketID
 		}
 
+		// look up existing pull requests to avoid opening duplicates
+		existing, err := listPullRequestsIn(localRepo, branchName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not look up existing pull requests:", err.Error())
+		}
+
 		// open pull requests for each destination branch
+		template := bodyTemplate(localRepo, defaults)
+		results := []pullRequestJSON{}
 		for _, destinationBranch := range pullBranches {
-			pullTitle := formatPRTitle(branchName, destinationBranch)
-			openPullRequest(pullTitle, destinationBranch, ticketID)
+			pullTitle := titles[destinationBranch]
+			options := pullRequestOptionsFor(cCtx, defaults, localRepo, destinationBranch)
+			result := pullRequestJSON{Base: destinationBranch, Head: branchName, Title: pullTitle, Result: "created"}
+
+			body := renderBody(localRepo, template, ticketID, destinationBranch)
+			if cCtx.Bool("edit") {
+				body, err = editText(body, "PULL_REQUEST.md")
+				if err == nil && body == "" {
+					err = fmt.Errorf("empty body, pull request aborted")
+				}
+				if err != nil {
+					result.Result = "failed"
+					result.Error = err.Error()
+					results = append(results, result)
+					continue
+				}
+			}
+
+			if pr, ok := existingPullRequest(existing, destinationBranch); ok {
+				result.URL = pr.URL
+				outcome, err := updateExistingPullRequest(cCtx, localRepo, pr, pullTitle, body, options)
+				result.Result = outcome
+				if err != nil {
+					result.Error = err.Error()
+					fmt.Fprintln(os.Stderr, icon("fail"), "Failed to update pull request #"+strconv.Itoa(pr.Number)+":", err.Error())
+				}
+			}
+			if result.Result == "created" {
+				url, err := openPullRequest(pullTitle, destinationBranch, body, options)
+				result.URL = url
+				if err != nil {
+					result.Result = "failed"
+					result.Error = err.Error()
+				}
+			}
+			results = append(results, result)
+		}
+
+		// summarize results
+		urls := []string{}
+		rows := [][]string{}
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+				rows = append(rows, []string{result.Base, icon("fail") + " " + result.Result, result.Error})
+				continue
+			}
+			urls = append(urls, result.URL)
+			rows = append(rows, []string{result.Base, icon("ok") + " " + result.Result, result.URL})
+		}
+		if output.json {
+			printJSON(results)
+		} else {
+			fmt.Println()
+			printTable([]string{"BASE", "RESULT", "URL"}, rows)
+		}
+
+		if len(urls) > 0 && cCtx.Bool("copy") {
+			if err := copyToClipboard(strings.Join(urls, "\n")); err != nil {
+				fmt.Fprintln(os.Stderr, "Could not copy to clipboard:", err.Error())
+			} else {
+				progressf("\nCopied %d url(s) to the clipboard\n", len(urls))
+			}
 		}
+		if cCtx.Bool("open") {
+			for _, url := range urls {
+				if err := openInBrowser(url); err != nil {
+					fmt.Fprintln(os.Stderr, "Could not open "+url+":", err.Error())
+				}
+			}
+		}
+
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("\n%d of %d pull requests failed", failed, len(results)), 1)
+		}
+		return nil
+	}
+}
 
+// repository defaults combined with command line flags
+func pullRequestOptionsFor(cCtx *cli.Context, defaults PullRequestDefaults, dir string, destinationBranch string) pullRequestOptions {
+	options := pullRequestOptions{
+		draft:     defaults.Draft || cCtx.Bool("draft"),
+		reviewers: appendUnique(defaults.Reviewers, cCtx.StringSlice("reviewer")...),
+		labels:    appendUnique(defaults.Labels, cCtx.StringSlice("label")...),
+		assignees: appendUnique(defaults.Assignees, cCtx.StringSlice("assignee")...),
+		milestone: defaults.Milestone,
+	}
+	if cCtx.IsSet("milestone") {
+		options.milestone = cCtx.String("milestone")
+	}
+	if defaults.CodeOwnersReviewers && !cCtx.Bool("no-codeowners") {
+		options.reviewers = appendUnique(options.reviewers, codeOwnersReviewers(dir, baseRefIn(dir, destinationBranch))...)
+	}
+	return options
+}
+
+// print titles without opening pull requests
+func previewTitles(branchName string, pullBranches []string, titles map[string]string) error {
+	results := []pullRequestJSON{}
+	rows := [][]string{}
+	for _, destinationBranch := range pullBranches {
+		results = append(results, pullRequestJSON{Base: destinationBranch, Head: branchName, Title: titles[destinationBranch], Result: "preview"})
+		rows = append(rows, []string{destinationBranch, titles[destinationBranch]})
+	}
+	if output.json {
+		return printJSON(results)
+	}
+	printTable([]string{"BASE", "TITLE"}, rows)
+	return nil
+}
+
+// push branch when it has no upstream or unpushed commits, asking unless --push
+func ensureBranchPushed(cCtx *cli.Context, dir string, branch string) error {
+	reason := ""
+	if upstream, err := getUpstreamIn(dir, branch); err != nil {
+		reason = "has no upstream"
+	} else if ahead, _, err := aheadBehindIn(dir, branch, upstream); err == nil && ahead > 0 {
+		reason = fmt.Sprintf("has %d unpushed commit(s)", ahead)
+	}
+	if reason == "" {
 		return nil
 	}
+
+	if !cCtx.Bool("push") && !confirm(fmt.Sprintf("Branch %s %s. Push it to origin?", branch, reason)) {
+		return fmt.Errorf("Branch %s must be pushed before opening pull requests (use --push)", branch)
+	}
+	outcome, _, err := pushBranchIn(dir, branch, false)
+	if err != nil {
+		return fmt.Errorf("Failed to push %s: %s", branch, err.Error())
+	}
+	fmt.Fprintln(progressWriter(), icon("ok"), branch+":", outcome)
+	return nil
+}
+
+// report, update or reopen a pull request that already exists for the
+// destination. Returns "created" when a new one should be opened instead.
+func updateExistingPullRequest(cCtx *cli.Context, dir string, pr pullRequestInfo, title string, body string, options pullRequestOptions) (string, error) {
+	fmt.Fprintf(progressWriter(), "Pull request #%d into %s is %s: %s\n", pr.Number, pr.BaseRefName, pr.displayState(), pr.URL)
+	if cCtx.Bool("skip-existing") {
+		return "existing", nil
+	}
+
+	if pr.State == "CLOSED" {
+		if !cCtx.Bool("update") && !confirm("Reopen and update it instead of opening a new one?") {
+			return "created", nil
+		}
+		if err := reopenPullRequestIn(dir, pr.Number); err != nil {
+			return "failed", err
+		}
+		if err := editPullRequestIn(dir, pr.Number, title, body, options); err != nil {
+			return "failed", err
+		}
+		fmt.Fprintln(progressWriter(), icon("ok"), "Reopened and updated")
+		return "reopened", nil
+	}
+
+	if !cCtx.Bool("update") && !confirm("Update its title and body?") {
+		return "existing", nil
+	}
+	if err := editPullRequestIn(dir, pr.Number, title, body, options); err != nil {
+		return "failed", err
+	}
+	fmt.Fprintln(progressWriter(), icon("ok"), "Updated")
+	return "updated", nil
 }
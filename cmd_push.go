@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+type pushResult struct {
+	state   State
+	skipped bool
+	outcome string
+	err     error
+}
+
+func pushCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		treeName, err := resolveTreeName(config, append(args, "")[0])
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		states, err := dependencyOrder(config, config.Trees[treeName].States)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		results := make([]pushResult, len(states))
+		progressf("%s\n", joinNonEmpty(fmt.Sprintf("Pushing %d repositories of tree %s", len(states), treeName), icon("log")))
+
+		runInDependencyOrder(config, states, cCtx.Int("parallel"), false, func(ctx context.Context, i int, state State) error {
+			results[i] = pushState(config, state, cCtx.Bool("force-with-lease"))
+			return results[i].err
+		})
+
+		// summarize results
+		rows := [][]string{}
+		jsonResults := []stateResultJSON{}
+		failed := 0
+		for _, result := range results {
+			if result.err != nil {
+				failed++
+			}
+			rows = append(rows, []string{result.state.Repo, result.state.Branch, formatOutcome(result.outcome, result.skipped, result.err)})
+			jsonResults = append(jsonResults, newStateResultJSON(result.state, result.outcome, result.skipped, result.err, 0, 0))
+		}
+		if output.json {
+			printJSON(jsonResults)
+		} else {
+			fmt.Println()
+			printTable([]string{"REPOSITORY", "BRANCH", "RESULT"}, rows)
+		}
+
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("\n%d of %d repositories failed to push", failed, len(states)), 1)
+		}
+		return nil
+	}
+}
+
+func pushState(config Configuration, state State, forceWithLease bool) pushResult {
+	result := pushResult{state: state}
+	repo, ok := config.Repositories[state.Repo]
+	if !ok {
+		result.err = fmt.Errorf("repository is not registered")
+		return result
+	}
+	if local, _ := branchExistsIn(repo.Local, state.Branch); !local {
+		result.outcome = "no local branch"
+		result.skipped = true
+		return result
+	}
+
+	result.outcome, result.skipped, result.err = pushBranchIn(repo.Local, state.Branch, forceWithLease)
+	return result
+}
+
+// push branch to origin, setting the upstream when missing
+func pushBranchIn(dir string, branch string, forceWithLease bool) (outcome string, skipped bool, err error) {
+	upstream, err := getUpstreamIn(dir, branch)
+	if err != nil {
+		if err := gitBackend.Push(dir, branch, true, forceWithLease); err != nil {
+			return "", false, fmt.Errorf("push rejected: %s", gitErrorMessage(err))
+		}
+		return "pushed, upstream set", false, nil
+	}
+
+	ahead, _, err := aheadBehindIn(dir, branch, upstream)
+	if err != nil {
+		return "", false, err
+	}
+	if ahead == 0 {
+		return "nothing to push", true, nil
+	}
+
+	if err := gitBackend.Push(dir, branch, false, forceWithLease); err != nil {
+		return "", false, fmt.Errorf("push rejected: %s", gitErrorMessage(err))
+	}
+	return fmt.Sprintf("pushed %d commit(s)", ahead), false, nil
+}
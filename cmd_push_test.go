@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPushState(t *testing.T) {
+	tests := []struct {
+		name           string
+		repo           fakeRepository
+		forceWithLease bool
+		outcome        string
+		skipped        bool
+		calls          []string
+	}{
+		{
+			name:    "no local branch",
+			repo:    fakeRepository{Branches: []string{"main"}},
+			outcome: "no local branch",
+			skipped: true,
+		},
+		{
+			name:    "no upstream",
+			repo:    fakeRepository{Branches: []string{"feature"}},
+			outcome: "pushed, upstream set",
+			calls:   []string{"push feature --set-upstream"},
+		},
+		{
+			name:    "nothing to push",
+			repo:    fakeRepository{Branches: []string{"feature"}, Upstreams: map[string]string{"feature": "origin/feature"}},
+			outcome: "nothing to push",
+			skipped: true,
+		},
+		{
+			name:    "unpushed commits",
+			repo:    fakeRepository{Branches: []string{"feature"}, Upstreams: map[string]string{"feature": "origin/feature"}, Counts: map[string][2]int{"feature...origin/feature": {2, 0}}},
+			outcome: "pushed 2 commit(s)",
+			calls:   []string{"push feature"},
+		},
+		{
+			name:           "force with lease",
+			repo:           fakeRepository{Branches: []string{"feature"}, Upstreams: map[string]string{"feature": "origin/feature"}, Counts: map[string][2]int{"feature...origin/feature": {1, 1}}},
+			forceWithLease: true,
+			outcome:        "pushed 1 commit(s)",
+			calls:          []string{"push feature --force-with-lease"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := useFakeGit(t)
+			repo := test.repo
+			fake.addRepository("/code/lib", &repo)
+			config := Configuration{Repositories: map[string]Repository{"acme/lib": {Local: "/code/lib"}}}
+
+			result := pushState(config, State{Repo: "acme/lib", Branch: "feature"}, test.forceWithLease)
+			if result.err != nil {
+				t.Fatalf("unexpected error: %s", result.err)
+			}
+			if result.outcome != test.outcome || result.skipped != test.skipped {
+				t.Errorf("got %q skipped=%t, want %q skipped=%t", result.outcome, result.skipped, test.outcome, test.skipped)
+			}
+			if !reflect.DeepEqual(repo.Calls, test.calls) {
+				t.Errorf("got calls %q, want %q", repo.Calls, test.calls)
+			}
+		})
+	}
+}
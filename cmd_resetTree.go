@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+func resetTreeCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		treeName, err := resolveTreeName(config, cCtx.Args().Get(0))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		// swap tree branches for default branches
+		repoNames := []string{}
+		for _, state := range config.Trees[treeName].States {
+			repoNames = append(repoNames, state.Repo)
+		}
+		states := defaultBranchStates(config, repoNames)
+		progressf("%s\n", joinNonEmpty("Checking out default branches of tree "+treeName, icon("log")))
+
+		results := make([]stateResultJSON, len(states))
+		runAcrossStates(states, cCtx.Int("parallel"), false, func(ctx context.Context, i int, state State) error {
+			results[i] = checkoutDefaultBranch(config, state)
+			return nil
+		})
+		return printStateResults(results, "failed to check out the default branch")
+	}
+}
+
+// states on the default branch of each repository
+func defaultBranchStates(config Configuration, repoNames []string) []State {
+	states := []State{}
+	for _, repoName := range repoNames {
+		state := State{Repo: repoName}
+		if _, ok := config.Repositories[repoName]; ok {
+			state.Branch = repositoryDefaultBranch(config, repoName)
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// check out the default branch unless there are uncommitted changes
+func checkoutDefaultBranch(config Configuration, state State) stateResultJSON {
+	repo, ok := config.Repositories[state.Repo]
+	if !ok {
+		return newStateResultJSON(state, "", false, fmt.Errorf("repository is not registered"), 0, 0)
+	}
+	if dirty, err := isDirtyIn(repo.Local); err != nil || dirty {
+		return newStateResultJSON(state, "uncommitted changes", true, nil, 0, 0)
+	}
+	if current, err := getBranchNameIn(repo.Local); err == nil && current == state.Branch {
+		return newStateResultJSON(state, "already checked out", true, nil, 0, 0)
+	}
+	if err := checkoutBranchIn(repo.Local, state.Branch); err != nil {
+		return newStateResultJSON(state, "", false, fmt.Errorf("checkout failed: %s", gitErrorMessage(err)), 0, 0)
+	}
+	return newStateResultJSON(state, "checked out", false, nil, 0, 0)
+}
+
+// summary table or json, exiting non-zero when any state failed
+func printStateResults(results []stateResultJSON, failure string) error {
+	failed := 0
+	rows := [][]string{}
+	for _, result := range results {
+		outcome := icon("ok") + " " + result.Result
+		switch result.Status {
+		case "failed":
+			failed++
+			outcome = icon("fail") + " " + result.Error
+		case "skipped":
+			outcome = icon("skip") + " " + result.Result
+		}
+		rows = append(rows, []string{result.Repo, result.Branch, outcome})
+	}
+	if output.json {
+		printJSON(results)
+	} else {
+		fmt.Println()
+		printTable([]string{"REPOSITORY", "BRANCH", "RESULT"}, rows)
+	}
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("\n%d of %d repositories %s", failed, len(results), failure), 1)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// repository with a main and a feature branch, on the feature branch
+func newTestRepository(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("lib\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "README.md"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+		{"checkout", "-q", "-b", "feature"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+		}
+	}
+	return dir
+}
+
+func TestCheckoutDefaultBranch(t *testing.T) {
+	dir := newTestRepository(t)
+	config := Configuration{
+		Repositories: map[string]Repository{"acme/lib": {Local: dir, DefaultBranch: "main"}},
+		Trees:        map[string]Tree{"t1": {Name: "t1", States: []State{{Repo: "acme/lib", Branch: "feature"}}}},
+	}
+
+	result := checkoutDefaultBranch(config, State{Repo: "acme/lib", Branch: "main"})
+	if result.Status != "ok" || result.Result != "checked out" {
+		t.Fatalf("got %s %q, want ok \"checked out\"", result.Status, result.Result)
+	}
+	if branch, _ := getBranchNameIn(dir); branch != "main" {
+		t.Errorf("checked out %s, want main", branch)
+	}
+	if branch := config.Trees["t1"].States[0].Branch; branch != "feature" {
+		t.Errorf("tree uses %s, want feature", branch)
+	}
+
+	result = checkoutDefaultBranch(config, State{Repo: "acme/lib", Branch: "main"})
+	if result.Status != "skipped" || result.Result != "already checked out" {
+		t.Errorf("got %s %q, want skipped \"already checked out\"", result.Status, result.Result)
+	}
+}
+
+func TestCheckoutDefaultBranchIsMarkedInternal(t *testing.T) {
+	dir := newTestRepository(t)
+	marker := filepath.Join(t.TempDir(), "marker")
+	hook := "#!/bin/sh\necho \"$" + internalGitEnv + "\" > " + marker + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ".git", "hooks", "post-checkout"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := Configuration{Repositories: map[string]Repository{"acme/lib": {Local: dir}}}
+
+	checkoutDefaultBranch(config, State{Repo: "acme/lib", Branch: "main"})
+	content, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("post-checkout hook did not run: %s", err)
+	}
+	if strings.TrimSpace(string(content)) != "1" {
+		t.Errorf("hook saw %s=%q, want 1", internalGitEnv, strings.TrimSpace(string(content)))
+	}
+}
+
+func TestCheckoutDefaultBranchKeepsChanges(t *testing.T) {
+	dir := newTestRepository(t)
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("wip\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := Configuration{Repositories: map[string]Repository{"acme/lib": {Local: dir}}}
+
+	result := checkoutDefaultBranch(config, State{Repo: "acme/lib", Branch: "main"})
+	if result.Status != "skipped" || result.Result != "uncommitted changes" {
+		t.Errorf("got %s %q, want skipped \"uncommitted changes\"", result.Status, result.Result)
+	}
+	if branch, _ := getBranchNameIn(dir); branch != "feature" {
+		t.Errorf("checked out %s, want feature", branch)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+)
+
+type statusJSON struct {
+	Repo          string `json:"repo"`
+	Branch        string `json:"branch"`
+	CurrentBranch string `json:"current_branch"`
+	OnBranch      bool   `json:"on_branch"`
+	Dirty         bool   `json:"dirty"`
+	Upstream      bool   `json:"upstream"`
+	Ahead         int    `json:"ahead"`
+	Behind        int    `json:"behind"`
+	Error         string `json:"error,omitempty"`
+}
+
+func statusCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		treeName, err := resolveTreeName(config, cCtx.Args().Get(0))
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		states, err := dependencyOrder(config, config.Trees[treeName].States)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		statuses := make([]repoStatus, len(states))
+		runAcrossStates(states, 8, false, func(ctx context.Context, i int, state State) error {
+			repo, ok := config.Repositories[state.Repo]
+			if !ok {
+				statuses[i] = repoStatus{err: fmt.Errorf("repository is not registered")}
+				return nil
+			}
+			statuses[i] = getRepoStatus(repo.Local, state.Branch)
+			return nil
+		})
+
+		if output.json {
+			results := []statusJSON{}
+			for i, state := range states {
+				status := statuses[i]
+				result := statusJSON{
+					Repo:          state.Repo,
+					Branch:        state.Branch,
+					CurrentBranch: status.branch,
+					OnBranch:      status.branch == state.Branch,
+					Dirty:         status.dirty,
+					Upstream:      status.upstream,
+					Ahead:         status.ahead,
+					Behind:        status.behind,
+				}
+				if status.err != nil {
+					result.Error = status.err.Error()
+				}
+				results = append(results, result)
+			}
+			return printJSON(results)
+		}
+
+		fmt.Println(highlightStyle.Render(treeName))
+		rows := [][]string{}
+		for i, state := range states {
+			status := statuses[i]
+			current := icon("ok")
+			if status.err != nil {
+				current = icon("fail") + " " + status.err.Error()
+			} else if status.branch != state.Branch {
+				current = "on " + status.branch
+			}
+			changes := ""
+			if status.dirty {
+				changes = "dirty"
+			}
+			tracking := "no upstream"
+			if status.upstream {
+				tracking = fmt.Sprintf("+%d -%d", status.ahead, status.behind)
+			}
+			rows = append(rows, []string{state.Repo, state.Branch, current, changes, tracking})
+		}
+		printTable([]string{"REPOSITORY", "BRANCH", "CURRENT", "CHANGES", "AHEAD/BEHIND"}, rows)
+		return nil
+	}
+}
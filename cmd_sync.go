@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strconv"
+	"strings"
+)
+
+type syncResult struct {
+	state    State
+	dir      string
+	ahead    int
+	behind   int
+	diverged bool
+	skipped  bool
+	outcome  string
+	err      error
+}
+
+func syncCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		args, err := positionalArgs(cCtx)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		treeName, err := resolveTreeName(config, append(args, "")[0])
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		strategy := cCtx.String("strategy")
+		if strategy != "" && strategy != "rebase" && strategy != "merge" && strategy != "skip" {
+			return cli.Exit("--strategy must be rebase, merge or skip", 1)
+		}
+
+		states, err := dependencyOrder(config, config.Trees[treeName].States)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		results := make([]syncResult, len(states))
+		progressf("%s\n", joinNonEmpty(fmt.Sprintf("Fetching %d repositories of tree %s", len(states), treeName), icon("log")))
+
+		// fetch and fast-forward in parallel
+		runInDependencyOrder(config, states, cCtx.Int("parallel"), false, func(ctx context.Context, i int, state State) error {
+			results[i] = fetchAndFastForward(config, state)
+			return results[i].err
+		})
+
+		// diverged branches need a strategy per repository
+		for i, result := range results {
+			if !result.diverged {
+				continue
+			}
+			repoStrategy := strategy
+			for repoStrategy == "" {
+				progressf("%s (%s) has diverged: %d ahead, %d behind. [r]ebase, [m]erge or [s]kip? ", result.state.Repo, result.state.Branch, result.ahead, result.behind)
+				answer, err := stdinReader.ReadString('\n')
+				switch strings.ToLower(strings.TrimSpace(answer)) {
+				case "r", "rebase":
+					repoStrategy = "rebase"
+				case "m", "merge":
+					repoStrategy = "merge"
+				case "s", "skip":
+					repoStrategy = "skip"
+				default:
+					if err != nil {
+						repoStrategy = "skip"
+					}
+				}
+			}
+			results[i] = integrateDiverged(result, repoStrategy)
+		}
+
+		// report what happened
+		rows := [][]string{}
+		jsonResults := []stateResultJSON{}
+		failed := 0
+		for _, result := range results {
+			if result.err != nil {
+				failed++
+			}
+			rows = append(rows, []string{
+				result.state.Repo,
+				result.state.Branch,
+				strconv.Itoa(result.ahead),
+				strconv.Itoa(result.behind),
+				formatOutcome(result.outcome, result.skipped, result.err),
+			})
+			jsonResults = append(jsonResults, newStateResultJSON(result.state, result.outcome, result.skipped, result.err, result.ahead, result.behind))
+		}
+		if output.json {
+			printJSON(jsonResults)
+		} else {
+			fmt.Println()
+			printTable([]string{"REPOSITORY", "BRANCH", "AHEAD", "BEHIND", "RESULT"}, rows)
+		}
+
+		if failed > 0 {
+			return cli.Exit(fmt.Sprintf("\n%d of %d repositories failed to sync", failed, len(states)), 1)
+		}
+		return nil
+	}
+}
+
+func fetchAndFastForward(config Configuration, state State) syncResult {
+	result := syncResult{state: state}
+	repo, ok := config.Repositories[state.Repo]
+	if !ok {
+		result.err = fmt.Errorf("repository is not registered")
+		return result
+	}
+	result.dir = repo.Local
+
+	if err := gitBackend.Fetch(repo.Local); err != nil {
+		result.err = fmt.Errorf("fetch failed: %s", gitErrorMessage(err))
+		return result
+	}
+
+	local, remote := branchExistsIn(repo.Local, state.Branch)
+	remoteBranch := "origin/" + state.Branch
+	if !remote {
+		result.outcome = "not on origin"
+		result.skipped = true
+		return result
+	}
+	if !local {
+		if err := gitBackend.TrackBranch(repo.Local, state.Branch, remoteBranch); err != nil {
+			result.err = fmt.Errorf("could not create branch: %s", gitErrorMessage(err))
+			return result
+		}
+		result.outcome = "created from origin"
+		return result
+	}
+
+	ahead, behind, err := aheadBehindIn(repo.Local, state.Branch, remoteBranch)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	result.ahead, result.behind = ahead, behind
+
+	switch {
+	case behind == 0 && ahead == 0:
+		result.outcome = "up to date"
+	case behind == 0:
+		result.outcome = "ahead of origin"
+	case ahead == 0:
+		if err := gitBackend.FastForward(repo.Local, state.Branch, remoteBranch); err != nil {
+			result.err = fmt.Errorf("fast-forward failed: %s", gitErrorMessage(err))
+		} else {
+			result.outcome = "fast-forwarded"
+		}
+	default:
+		result.diverged = true
+	}
+	return result
+}
+
+func integrateDiverged(result syncResult, strategy string) syncResult {
+	if strategy == "skip" {
+		result.outcome = "diverged"
+		result.skipped = true
+		return result
+	}
+
+	dirty, err := isDirtyIn(result.dir)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if dirty {
+		result.err = fmt.Errorf("diverged, working tree has uncommitted changes")
+		return result
+	}
+
+	// strategies need the branch checked out
+	previousBranch, err := getBranchNameIn(result.dir)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if previousBranch != result.state.Branch {
+		if err := checkoutBranchIn(result.dir, result.state.Branch); err != nil {
+			result.err = fmt.Errorf("checkout failed: %s", gitErrorMessage(err))
+			return result
+		}
+		defer checkoutBranchIn(result.dir, previousBranch)
+	}
+
+	remoteBranch := "origin/" + result.state.Branch
+	if strategy == "rebase" {
+		if err := gitBackend.Rebase(result.dir, remoteBranch); err != nil {
+			result.err = fmt.Errorf("rebase has conflicts, aborted")
+			return result
+		}
+		result.outcome = "rebased"
+	} else {
+		if err := gitBackend.Merge(result.dir, remoteBranch); err != nil {
+			result.err = fmt.Errorf("merge has conflicts, aborted")
+			return result
+		}
+		result.outcome = "merged"
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFetchAndFastForward(t *testing.T) {
+	tests := []struct {
+		name     string
+		repo     fakeRepository
+		outcome  string
+		skipped  bool
+		diverged bool
+		calls    []string
+	}{
+		{
+			name:    "not on origin",
+			repo:    fakeRepository{Branches: []string{"main", "feature"}, RemoteBranches: []string{"main"}},
+			outcome: "not on origin",
+			skipped: true,
+			calls:   []string{"fetch"},
+		},
+		{
+			name:    "created from origin",
+			repo:    fakeRepository{Branches: []string{"main"}, RemoteBranches: []string{"main", "feature"}},
+			outcome: "created from origin",
+			calls:   []string{"fetch", "track feature"},
+		},
+		{
+			name:    "up to date",
+			repo:    fakeRepository{Branches: []string{"feature"}, RemoteBranches: []string{"feature"}},
+			outcome: "up to date",
+			calls:   []string{"fetch"},
+		},
+		{
+			name:    "ahead",
+			repo:    fakeRepository{Branches: []string{"feature"}, RemoteBranches: []string{"feature"}, Counts: map[string][2]int{"feature...origin/feature": {2, 0}}},
+			outcome: "ahead of origin",
+			calls:   []string{"fetch"},
+		},
+		{
+			name:    "behind",
+			repo:    fakeRepository{Branches: []string{"feature"}, RemoteBranches: []string{"feature"}, Counts: map[string][2]int{"feature...origin/feature": {0, 3}}},
+			outcome: "fast-forwarded",
+			calls:   []string{"fetch", "fast-forward feature to origin/feature"},
+		},
+		{
+			name:     "diverged",
+			repo:     fakeRepository{Branches: []string{"feature"}, RemoteBranches: []string{"feature"}, Counts: map[string][2]int{"feature...origin/feature": {1, 1}}},
+			diverged: true,
+			calls:    []string{"fetch"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := useFakeGit(t)
+			repo := test.repo
+			fake.addRepository("/code/lib", &repo)
+			config := Configuration{Repositories: map[string]Repository{"acme/lib": {Local: "/code/lib"}}}
+
+			result := fetchAndFastForward(config, State{Repo: "acme/lib", Branch: "feature"})
+			if result.err != nil {
+				t.Fatalf("unexpected error: %s", result.err)
+			}
+			if result.outcome != test.outcome || result.skipped != test.skipped || result.diverged != test.diverged {
+				t.Errorf("got %q skipped=%t diverged=%t, want %q skipped=%t diverged=%t", result.outcome, result.skipped, result.diverged, test.outcome, test.skipped, test.diverged)
+			}
+			if !reflect.DeepEqual(repo.Calls, test.calls) {
+				t.Errorf("got calls %q, want %q", repo.Calls, test.calls)
+			}
+		})
+	}
+}
+
+func TestFetchAndFastForwardUnregistered(t *testing.T) {
+	useFakeGit(t)
+	result := fetchAndFastForward(Configuration{}, State{Repo: "acme/lib", Branch: "feature"})
+	if result.err == nil || result.err.Error() != "repository is not registered" {
+		t.Errorf("got error %v, want repository is not registered", result.err)
+	}
+}
+
+func TestIntegrateDiverged(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  string
+		dirty     bool
+		conflicts bool
+		outcome   string
+		err       string
+		calls     []string
+	}{
+		{name: "skip", strategy: "skip", outcome: "diverged"},
+		{name: "rebase", strategy: "rebase", outcome: "rebased", calls: []string{"rebase feature onto origin/feature"}},
+		{name: "merge", strategy: "merge", outcome: "merged", calls: []string{"merge feature onto origin/feature"}},
+		{name: "rebase conflicts", strategy: "rebase", conflicts: true, err: "rebase has conflicts, aborted", calls: []string{"rebase --abort"}},
+		{name: "merge conflicts", strategy: "merge", conflicts: true, err: "merge has conflicts, aborted", calls: []string{"merge --abort"}},
+		{name: "dirty", strategy: "rebase", dirty: true, err: "diverged, working tree has uncommitted changes"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := useFakeGit(t)
+			repo := &fakeRepository{Current: "main", Branches: []string{"main", "feature"}, Dirty: test.dirty, Conflicts: test.conflicts}
+			fake.addRepository("/code/lib", repo)
+
+			result := integrateDiverged(syncResult{state: State{Repo: "acme/lib", Branch: "feature"}, dir: "/code/lib", diverged: true}, test.strategy)
+			if test.err != "" {
+				if result.err == nil || result.err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", result.err, test.err)
+				}
+			} else if result.err != nil || result.outcome != test.outcome {
+				t.Fatalf("got %q, %v, want %q", result.outcome, result.err, test.outcome)
+			}
+			if !reflect.DeepEqual(repo.Calls, test.calls) {
+				t.Errorf("got calls %q, want %q", repo.Calls, test.calls)
+			}
+			// the previously checked out branch is restored
+			if repo.Current != "main" {
+				t.Errorf("left %s checked out, want main", repo.Current)
+			}
+		})
+	}
+}
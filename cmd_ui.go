@@ -0,0 +1,13 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/urfave/cli/v2"
+)
+
+func uiCmdAction(config Configuration) cli.ActionFunc {
+	return func(cCtx *cli.Context) error {
+		p := tea.NewProgram(dashboardInitialModel(config), tea.WithAltScreen())
+		return p.Start()
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// saves are not locked on windows
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// git operations bsync relies on. Every method takes the repository
+// directory, where "" is the current directory.
+type Git interface {
+	TopLevel(dir string) (string, error)
+	RemoteURL(dir string) (string, error)
+	CurrentBranch(dir string) (string, error)
+	ListBranches(dir string) ([]string, error)
+	BranchExists(dir string, branch string) (local bool, remote bool)
+	DefaultBranch(dir string) (string, error)
+	IsDirty(dir string) (bool, error)
+	Upstream(dir string, branch string) (string, error)
+	AheadBehind(dir string, local string, other string) (ahead int, behind int, err error)
+	Checkout(dir string, branch string) error
+	Pull(dir string, branch string) error
+	Fetch(dir string) error
+
+	// branches
+	CreateBranch(dir string, branch string, from string) error
+	TrackBranch(dir string, branch string, remoteBranch string) error
+	DeleteBranch(dir string, branch string) error
+	BranchDetails(dir string) ([]branchDetail, error)
+	MergedBranches(dir string, base string) ([]string, error)
+	FastForward(dir string, branch string, target string) error
+	Rebase(dir string, onto string) error
+	Merge(dir string, other string) error
+	Push(dir string, branch string, setUpstream bool, forceWithLease bool) error
+
+	// history
+	ResolveCommit(dir string, ref string) (string, error)
+	IsAncestor(dir string, ancestor string, ref string) bool
+	FirstParentHistory(dir string, ref string) ([]string, error)
+	Reflog(dir string, branch string) ([]string, error)
+	CommitSubjects(dir string, base string) ([]string, error)
+	CommitTrailers(dir string, base string) ([]string, error)
+	DiffStat(dir string, base string) (string, error)
+	ChangedFiles(dir string, base string) ([]string, error)
+
+	HooksDir(dir string) (string, error)
+	UserEmail(dir string) (string, error)
+}
+
+// local branch with what prune needs to know about it
+type branchDetail struct {
+	Name         string
+	UpstreamGone bool
+	// unix time of the last commit
+	Committed int64
+}
+
+// backend used by the git helpers, chosen by git_backend in the
+// configuration and replaced in tests
+var gitBackend Git = execGit{}
+
+// runs the git binary
+type execGit struct{}
+
+func (execGit) TopLevel(dir string) (string, error) {
+	return runGitIn(dir, "rev-parse", "--show-toplevel")
+}
+
+func (execGit) RemoteURL(dir string) (string, error) {
+	return runGitIn(dir, "config", "--get", "remote.origin.url")
+}
+
+func (execGit) CurrentBranch(dir string) (string, error) {
+	return runGitIn(dir, "rev-parse", "--abbrev-ref", "HEAD")
+}
+
+func (execGit) ListBranches(dir string) ([]string, error) {
+	out, err := runGitIn(dir, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (execGit) BranchExists(dir string, branch string) (local bool, remote bool) {
+	_, err := runGitIn(dir, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	local = err == nil
+	_, err = runGitIn(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch)
+	remote = err == nil
+	return local, remote
+}
+
+// branch origin/HEAD points to
+func (execGit) DefaultBranch(dir string) (string, error) {
+	ref, err := runGitIn(dir, "symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(ref, "origin/"), nil
+}
+
+// uncommitted changes to tracked files
+func (execGit) IsDirty(dir string) (bool, error) {
+	out, err := runGitIn(dir, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+func (execGit) Upstream(dir string, branch string) (string, error) {
+	return runGitIn(dir, "rev-parse", "--abbrev-ref", branch+"@{upstream}")
+}
+
+func (execGit) AheadBehind(dir string, local string, other string) (ahead int, behind int, err error) {
+	out, err := runGitIn(dir, "rev-list", "--left-right", "--count", local+"..."+other)
+	if err != nil {
+		return 0, 0, err
+	}
+	_, err = fmt.Sscanf(out, "%d\t%d", &ahead, &behind)
+	return ahead, behind, err
+}
+
+func (execGit) Checkout(dir string, branch string) error {
+	_, err := runGitIn(dir, "checkout", branch)
+	return err
+}
+
+// fast-forward only
+func (execGit) Pull(dir string, branch string) error {
+	_, err := runGitIn(dir, "pull", "--ff-only", "origin", branch)
+	return err
+}
+
+func (execGit) Fetch(dir string) error {
+	_, err := runGitIn(dir, "fetch", "origin")
+	return err
+}
+
+// create branch from a start point without tracking it, and check it out
+func (execGit) CreateBranch(dir string, branch string, from string) error {
+	_, err := runGitIn(dir, "checkout", "--no-track", "-b", branch, from)
+	return err
+}
+
+// create branch from a remote branch it tracks, without checking it out
+func (execGit) TrackBranch(dir string, branch string, remoteBranch string) error {
+	_, err := runGitIn(dir, "branch", "--track", branch, remoteBranch)
+	return err
+}
+
+// delete branch whether or not it is merged
+func (execGit) DeleteBranch(dir string, branch string) error {
+	_, err := runGitIn(dir, "branch", "-D", branch)
+	return err
+}
+
+func (execGit) BranchDetails(dir string) ([]branchDetail, error) {
+	out, err := runGitIn(dir, "for-each-ref", "refs/heads", "--format=%(refname:short)%09%(upstream:track)%09%(committerdate:unix)")
+	if err != nil {
+		return nil, err
+	}
+	details := []branchDetail{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		committed, _ := strconv.ParseInt(fields[2], 10, 64)
+		details = append(details, branchDetail{Name: fields[0], UpstreamGone: fields[1] == "[gone]", Committed: committed})
+	}
+	return details, nil
+}
+
+// local branches whose tip is reachable from base
+func (execGit) MergedBranches(dir string, base string) ([]string, error) {
+	out, err := runGitIn(dir, "branch", "--format=%(refname:short)", "--merged", base)
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// fast-forward branch to target, whether or not it is checked out
+func (g execGit) FastForward(dir string, branch string, target string) error {
+	currentBranch, err := g.CurrentBranch(dir)
+	if err != nil {
+		return err
+	}
+	if currentBranch == branch {
+		_, err = runGitIn(dir, "merge", "--ff-only", target)
+		return err
+	}
+	_, err = runGitIn(dir, "fetch", ".", target+":"+branch)
+	return err
+}
+
+// rebase the checked out branch, aborting on conflicts
+func (execGit) Rebase(dir string, onto string) error {
+	if _, err := runGitIn(dir, "rebase", onto); err != nil {
+		runGitIn(dir, "rebase", "--abort")
+		return err
+	}
+	return nil
+}
+
+// merge into the checked out branch, aborting on conflicts
+func (execGit) Merge(dir string, other string) error {
+	if _, err := runGitIn(dir, "merge", "--no-edit", other); err != nil {
+		runGitIn(dir, "merge", "--abort")
+		return err
+	}
+	return nil
+}
+
+func (execGit) Push(dir string, branch string, setUpstream bool, forceWithLease bool) error {
+	args := []string{"push"}
+	if forceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	if setUpstream {
+		args = append(args, "--set-upstream")
+	}
+	_, err := runGitIn(dir, append(args, "origin", branch)...)
+	return err
+}
+
+func (execGit) ResolveCommit(dir string, ref string) (string, error) {
+	return runGitIn(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+}
+
+func (execGit) IsAncestor(dir string, ancestor string, ref string) bool {
+	_, err := runGitIn(dir, "merge-base", "--is-ancestor", ancestor, ref)
+	return err == nil
+}
+
+func (execGit) FirstParentHistory(dir string, ref string) ([]string, error) {
+	out, err := runGitIn(dir, "rev-list", "--first-parent", ref)
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// reflog messages of a local branch, newest first
+func (execGit) Reflog(dir string, branch string) ([]string, error) {
+	out, err := runGitIn(dir, "reflog", "show", "--format=%gs", "refs/heads/"+branch, "--")
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// subjects of commits between base and HEAD without merges, oldest first
+func (execGit) CommitSubjects(dir string, base string) ([]string, error) {
+	out, err := runGitIn(dir, "log", "--reverse", "--no-merges", "--format=%s", base+"..HEAD")
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// trailer lines of commits between base and HEAD without merges
+func (execGit) CommitTrailers(dir string, base string) ([]string, error) {
+	out, err := runGitIn(dir, "log", "--no-merges", "--format=%(trailers:only,unfold)", base+"..HEAD")
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// summary of changes since HEAD diverged from base
+func (execGit) DiffStat(dir string, base string) (string, error) {
+	return runGitIn(dir, "diff", "--shortstat", base+"...HEAD")
+}
+
+// files changed since HEAD diverged from base
+func (execGit) ChangedFiles(dir string, base string) ([]string, error) {
+	out, err := runGitIn(dir, "diff", "--name-only", base+"...HEAD")
+	if err != nil || out == "" {
+		return []string{}, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+func (execGit) HooksDir(dir string) (string, error) {
+	return runGitIn(dir, "rev-parse", "--git-path", "hooks")
+}
+
+// user.email from the repository, global or system configuration
+func (execGit) UserEmail(dir string) (string, error) {
+	return runGitIn(dir, "config", "--get", "user.email")
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	gogit "github.com/go-git/go-git/v5"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const benchmarkRepositories = 20
+
+// worktree sizes benchmarks run against, in files per repository
+var benchmarkWorktrees = []int{10, 3000}
+
+// repositories with the given number of files and some history, main
+// tracking a branch one commit behind it and every other one dirty
+func benchmarkRepositoryDirs(b *testing.B, files int) []string {
+	b.Helper()
+	root := b.TempDir()
+	dirs := []string{}
+	for i := 0; i < benchmarkRepositories; i++ {
+		dir := filepath.Join(root, fmt.Sprintf("repo%d", i))
+		if err := createBenchmarkRepository(dir, files, i%2 == 1); err != nil {
+			b.Fatal(err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func createBenchmarkRepository(dir string, files int, dirty bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	git := func(args ...string) error {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=bench", "-c", "user.email=bench@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %s", strings.Join(args, " "), out)
+		}
+		return nil
+	}
+	if err := git("init", "-q", "-b", "main"); err != nil {
+		return err
+	}
+	for i := 0; i < files; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(file, []byte(strings.Repeat("line\n", 100)), 0644); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < 50; i++ {
+		file := filepath.Join(dir, fmt.Sprintf("file%d.txt", i%files))
+		if err := os.WriteFile(file, []byte(fmt.Sprintf("change %d\n", i)), 0644); err != nil {
+			return err
+		}
+		if err := git("add", "."); err != nil {
+			return err
+		}
+		if err := git("commit", "-q", "-m", fmt.Sprintf("change %d", i)); err != nil {
+			return err
+		}
+	}
+	if err := git("branch", "base", "HEAD~1"); err != nil {
+		return err
+	}
+	if err := git("branch", "--set-upstream-to=base", "main"); err != nil {
+		return err
+	}
+	if dirty {
+		return os.WriteFile(filepath.Join(dir, "file0.txt"), []byte("uncommitted\n"), 0644)
+	}
+	return nil
+}
+
+// each backend against the same repositories
+func benchmarkBackends(b *testing.B, files int, run func(b *testing.B, dirs []string)) {
+	dirs := benchmarkRepositoryDirs(b, files)
+	for _, name := range []string{"exec", "go-git"} {
+		backend, err := newGitBackend(name)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(name, func(b *testing.B) {
+			previous := gitBackend
+			gitBackend = backend
+			defer func() { gitBackend = previous }()
+			b.ResetTimer()
+			run(b, dirs)
+		})
+	}
+}
+
+// status of every repository as bsync status shows it
+func BenchmarkStatus(b *testing.B) {
+	for _, files := range benchmarkWorktrees {
+		b.Run(fmt.Sprintf("files=%d", files), func(b *testing.B) {
+			benchmarkBackends(b, files, func(b *testing.B, dirs []string) {
+				for n := 0; n < b.N; n++ {
+					for _, dir := range dirs {
+						if status := getRepoStatus(dir, "main"); status.err != nil || !status.upstream || status.ahead != 1 {
+							b.Fatalf("%s: unexpected status %+v", dir, status)
+						}
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkListBranches(b *testing.B) {
+	benchmarkBackends(b, benchmarkWorktrees[0], func(b *testing.B, dirs []string) {
+		for n := 0; n < b.N; n++ {
+			for _, dir := range dirs {
+				if branches, err := gitBackend.ListBranches(dir); err != nil || len(branches) != 2 {
+					b.Fatalf("%s: got %v, %v", dir, branches, err)
+				}
+			}
+		}
+	})
+}
+
+// git status against go-git's worktree.Status(), which the go-git backend
+// doesn't use: it hashes every file and is far slower on large worktrees
+func BenchmarkWorktreeStatus(b *testing.B) {
+	for _, files := range benchmarkWorktrees {
+		dirs := benchmarkRepositoryDirs(b, files)
+		b.Run(fmt.Sprintf("files=%d/exec", files), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, dir := range dirs {
+					if _, err := runGitIn(dir, "status", "--porcelain", "--untracked-files=no"); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("files=%d/go-git", files), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, dir := range dirs {
+					repo, err := gogit.PlainOpen(dir)
+					if err != nil {
+						b.Fatal(err)
+					}
+					worktree, err := repo.Worktree()
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := worktree.Status(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// state of a repository in fakeGit
+type fakeRepository struct {
+	TopLevel       string
+	Remote         string
+	Current        string
+	Branches       []string
+	RemoteBranches []string
+	DefaultBranch  string
+	Dirty          bool
+	// upstream per local branch, e.g. "feature": "origin/feature"
+	Upstreams map[string]string
+	// ahead and behind per "local...other" range
+	Counts map[string][2]int
+	// local branches merged into the default branch
+	Merged []string
+	// local branches whose upstream was deleted
+	Gone []string
+	// unix time of the last commit per local branch
+	Committed map[string]int64
+	// commit per ref, and the first-parent history of refs
+	Commits map[string]string
+	History map[string][]string
+	Reflogs map[string][]string
+	// commits, trailers and changes between the default branch and HEAD
+	Subjects  []string
+	Trailers  []string
+	DiffStat  string
+	Changed   []string
+	UserEmail string
+	// rebase or merge of a diverged branch fails
+	Conflicts bool
+	// operations that changed branches or talked to the remote, in order
+	Calls []string
+}
+
+var _ Git = (*fakeGit)(nil)
+
+// in-memory Git for tests, keyed by repository directory
+type fakeGit struct {
+	mu    sync.Mutex
+	repos map[string]*fakeRepository
+}
+
+func newFakeGit() *fakeGit {
+	return &fakeGit{repos: map[string]*fakeRepository{}}
+}
+
+func (g *fakeGit) addRepository(dir string, repo *fakeRepository) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if repo.TopLevel == "" {
+		repo.TopLevel = dir
+	}
+	g.repos[dir] = repo
+}
+
+// repository at dir, called with the lock held
+func (g *fakeGit) repository(dir string) (*fakeRepository, error) {
+	repo, ok := g.repos[dir]
+	if !ok {
+		return nil, fmt.Errorf("not a git repository: %s", dir)
+	}
+	return repo, nil
+}
+
+func (g *fakeGit) TopLevel(dir string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	return repo.TopLevel, nil
+}
+
+func (g *fakeGit) RemoteURL(dir string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	if repo.Remote == "" {
+		return "", fmt.Errorf("no origin remote")
+	}
+	return repo.Remote, nil
+}
+
+func (g *fakeGit) CurrentBranch(dir string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	return repo.Current, nil
+}
+
+func (g *fakeGit) ListBranches(dir string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.Branches...), nil
+}
+
+func (g *fakeGit) BranchExists(dir string, branch string) (local bool, remote bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return false, false
+	}
+	return getIndex(repo.Branches, branch) != -1, getIndex(repo.RemoteBranches, branch) != -1
+}
+
+func (g *fakeGit) DefaultBranch(dir string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	if repo.DefaultBranch == "" {
+		return "", fmt.Errorf("origin/HEAD is not set")
+	}
+	return repo.DefaultBranch, nil
+}
+
+func (g *fakeGit) IsDirty(dir string) (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return false, err
+	}
+	return repo.Dirty, nil
+}
+
+func (g *fakeGit) Upstream(dir string, branch string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	upstream, ok := repo.Upstreams[branch]
+	if !ok {
+		return "", fmt.Errorf("no upstream configured for branch '%s'", branch)
+	}
+	return upstream, nil
+}
+
+func (g *fakeGit) AheadBehind(dir string, local string, other string) (int, int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	counts := repo.Counts[local+"..."+other]
+	return counts[0], counts[1], nil
+}
+
+// like git, checking out a branch that only exists on origin creates it
+func (g *fakeGit) Checkout(dir string, branch string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	if getIndex(repo.Branches, branch) == -1 {
+		if getIndex(repo.RemoteBranches, branch) == -1 {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to git", branch)
+		}
+		repo.Branches = append(repo.Branches, branch)
+	}
+	if repo.Dirty {
+		return fmt.Errorf("your local changes would be overwritten by checkout")
+	}
+	repo.Current = branch
+	return nil
+}
+
+func (g *fakeGit) Pull(dir string, branch string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	if getIndex(repo.RemoteBranches, branch) == -1 {
+		return fmt.Errorf("couldn't find remote ref %s", branch)
+	}
+	repo.Calls = append(repo.Calls, "pull "+branch)
+	return nil
+}
+
+func (g *fakeGit) Fetch(dir string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	repo.Calls = append(repo.Calls, "fetch")
+	return nil
+}
+
+func (g *fakeGit) CreateBranch(dir string, branch string, from string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	if getIndex(repo.Branches, branch) != -1 {
+		return fmt.Errorf("a branch named '%s' already exists", branch)
+	}
+	repo.Branches = append(repo.Branches, branch)
+	repo.Current = branch
+	repo.Calls = append(repo.Calls, "create "+branch+" from "+from)
+	return nil
+}
+
+func (g *fakeGit) TrackBranch(dir string, branch string, remoteBranch string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	repo.Branches = append(repo.Branches, branch)
+	if repo.Upstreams == nil {
+		repo.Upstreams = map[string]string{}
+	}
+	repo.Upstreams[branch] = remoteBranch
+	repo.Calls = append(repo.Calls, "track "+branch)
+	return nil
+}
+
+func (g *fakeGit) DeleteBranch(dir string, branch string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	i := getIndex(repo.Branches, branch)
+	if i == -1 {
+		return fmt.Errorf("branch '%s' not found", branch)
+	}
+	repo.Branches = append(repo.Branches[:i], repo.Branches[i+1:]...)
+	repo.Calls = append(repo.Calls, "delete "+branch)
+	return nil
+}
+
+func (g *fakeGit) BranchDetails(dir string) ([]branchDetail, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return nil, err
+	}
+	details := []branchDetail{}
+	for _, branch := range repo.Branches {
+		details = append(details, branchDetail{
+			Name:         branch,
+			UpstreamGone: getIndex(repo.Gone, branch) != -1,
+			Committed:    repo.Committed[branch],
+		})
+	}
+	return details, nil
+}
+
+func (g *fakeGit) MergedBranches(dir string, base string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.Merged...), nil
+}
+
+func (g *fakeGit) FastForward(dir string, branch string, target string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	repo.Calls = append(repo.Calls, "fast-forward "+branch+" to "+target)
+	return nil
+}
+
+func (g *fakeGit) Rebase(dir string, onto string) error {
+	return g.integrate(dir, "rebase", onto)
+}
+
+func (g *fakeGit) Merge(dir string, other string) error {
+	return g.integrate(dir, "merge", other)
+}
+
+// rebase or merge into the current branch, failing on conflicts
+func (g *fakeGit) integrate(dir string, operation string, other string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	if repo.Conflicts {
+		repo.Calls = append(repo.Calls, operation+" --abort")
+		return fmt.Errorf("could not apply %s", other)
+	}
+	repo.Calls = append(repo.Calls, operation+" "+repo.Current+" onto "+other)
+	return nil
+}
+
+func (g *fakeGit) Push(dir string, branch string, setUpstream bool, forceWithLease bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return err
+	}
+	call := "push " + branch
+	if setUpstream {
+		if repo.Upstreams == nil {
+			repo.Upstreams = map[string]string{}
+		}
+		repo.Upstreams[branch] = "origin/" + branch
+		call += " --set-upstream"
+	}
+	if forceWithLease {
+		call += " --force-with-lease"
+	}
+	repo.Calls = append(repo.Calls, call)
+	return nil
+}
+
+func (g *fakeGit) ResolveCommit(dir string, ref string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	commit, ok := repo.Commits[ref]
+	if !ok {
+		return "", fmt.Errorf("unknown revision %s", ref)
+	}
+	return commit, nil
+}
+
+// merged branches are ancestors of every ref
+func (g *fakeGit) IsAncestor(dir string, ancestor string, ref string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return false
+	}
+	return getIndex(repo.Merged, strings.TrimPrefix(ancestor, "refs/heads/")) != -1
+}
+
+func (g *fakeGit) FirstParentHistory(dir string, ref string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.History[ref]...), nil
+}
+
+func (g *fakeGit) Reflog(dir string, branch string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.Reflogs[branch]...), nil
+}
+
+func (g *fakeGit) CommitSubjects(dir string, base string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.Subjects...), nil
+}
+
+func (g *fakeGit) CommitTrailers(dir string, base string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.Trailers...), nil
+}
+
+func (g *fakeGit) DiffStat(dir string, base string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	return repo.DiffStat, nil
+}
+
+func (g *fakeGit) ChangedFiles(dir string, base string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	return append([]string{}, repo.Changed...), nil
+}
+
+func (g *fakeGit) HooksDir(dir string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	return repo.TopLevel + "/.git/hooks", nil
+}
+
+func (g *fakeGit) UserEmail(dir string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	repo, err := g.repository(dir)
+	if err != nil {
+		return "", err
+	}
+	if repo.UserEmail == "" {
+		return "", fmt.Errorf("user.email is not set")
+	}
+	return repo.UserEmail, nil
+}
+
+// use a fake backend for the rest of the test
+func useFakeGit(t *testing.T) *fakeGit {
+	t.Helper()
+	previous := gitBackend
+	fake := newFakeGit()
+	gitBackend = fake
+	t.Cleanup(func() {
+		gitBackend = previous
+	})
+	return fake
+}
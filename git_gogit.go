@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	gogit "github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"strings"
+)
+
+// reads repositories natively with go-git and runs git only for operations
+// that change them or talk to the remote. Dirty checks and ahead and behind
+// counts also run git: go-git hashes every file of the worktree and walks
+// the whole history of both sides, see git_bench_test.go.
+type goGit struct {
+	execGit
+}
+
+// git backend selected in the configuration
+func newGitBackend(name string) (Git, error) {
+	switch name {
+	case "", "exec":
+		return execGit{}, nil
+	case "go-git":
+		return goGit{}, nil
+	}
+	return nil, fmt.Errorf("unknown git backend %q, expected exec or go-git", name)
+}
+
+func (goGit) open(dir string) (*gogit.Repository, error) {
+	if dir == "" {
+		dir = "."
+	}
+	return gogit.PlainOpenWithOptions(dir, &gogit.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+}
+
+func (g goGit) TopLevel(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return worktree.Filesystem.Root(), nil
+}
+
+func (g goGit) RemoteURL(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return "", err
+	}
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		return urls[0], nil
+	}
+	return "", fmt.Errorf("origin has no url")
+}
+
+// "HEAD" when detached, like git rev-parse --abbrev-ref
+func (g goGit) CurrentBranch(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() == plumbing.SymbolicReference && head.Target().IsBranch() {
+		return head.Target().Short(), nil
+	}
+	return "HEAD", nil
+}
+
+func (g goGit) ListBranches(dir string) ([]string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return []string{}, err
+	}
+	branches, err := repo.Branches()
+	if err != nil {
+		return []string{}, err
+	}
+	names := []string{}
+	err = branches.ForEach(func(ref *plumbing.Reference) error {
+		names = append(names, ref.Name().Short())
+		return nil
+	})
+	return names, err
+}
+
+func (g goGit) BranchExists(dir string, branch string) (local bool, remote bool) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return false, false
+	}
+	_, err = repo.Reference(plumbing.NewBranchReferenceName(branch), false)
+	local = err == nil
+	_, err = repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), false)
+	remote = err == nil
+	return local, remote
+}
+
+func (g goGit) DefaultBranch(dir string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	ref, err := repo.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false)
+	if err != nil {
+		return "", err
+	}
+	if ref.Type() != plumbing.SymbolicReference {
+		return "", fmt.Errorf("origin/HEAD is not a symbolic reference")
+	}
+	return strings.TrimPrefix(ref.Target().String(), "refs/remotes/origin/"), nil
+}
+
+func (g goGit) Upstream(dir string, branch string) (string, error) {
+	repo, err := g.open(dir)
+	if err != nil {
+		return "", err
+	}
+	config, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+	tracking, ok := config.Branches[branch]
+	if !ok || tracking.Remote == "" || tracking.Merge == "" {
+		return "", fmt.Errorf("no upstream configured for branch '%s'", branch)
+	}
+	// like @{upstream}, fail when the tracked ref no longer exists
+	upstream := tracking.Merge
+	if tracking.Remote != "." {
+		upstream = plumbing.NewRemoteReferenceName(tracking.Remote, tracking.Merge.Short())
+	}
+	if _, err := repo.Reference(upstream, false); err != nil {
+		return "", fmt.Errorf("upstream '%s' of branch '%s' does not exist", upstream.Short(), branch)
+	}
+	return upstream.Short(), nil
+}
+
+// outside a repository only the global and system configuration apply
+func (g goGit) UserEmail(dir string) (string, error) {
+	var config *gitconfig.Config
+	repo, err := g.open(dir)
+	if err == nil {
+		config, err = repo.ConfigScoped(gitconfig.SystemScope)
+	} else if err == gogit.ErrRepositoryNotExists {
+		config, err = gitconfig.LoadConfig(gitconfig.GlobalScope)
+		if err == nil && config.User.Email == "" {
+			config, err = gitconfig.LoadConfig(gitconfig.SystemScope)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if config.User.Email == "" {
+		return "", fmt.Errorf("user.email is not set")
+	}
+	return config.User.Email, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestUpstreamBackends(t *testing.T) {
+	dir := newTestRepository(t)
+	for _, args := range [][]string{
+		{"remote", "add", "origin", "https://example.com/acme/lib.git"},
+		{"update-ref", "refs/remotes/origin/feature", "HEAD"},
+		{"config", "branch.feature.remote", "origin"},
+		{"config", "branch.feature.merge", "refs/heads/feature"},
+		{"config", "branch.main.remote", "."},
+		{"config", "branch.main.merge", "refs/heads/feature"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+		}
+	}
+
+	for name, backend := range map[string]Git{"exec": execGit{}, "go-git": goGit{}} {
+		if upstream, err := backend.Upstream(dir, "feature"); err != nil || upstream != "origin/feature" {
+			t.Errorf("%s: got %q, %v, want origin/feature", name, upstream, err)
+		}
+		if upstream, err := backend.Upstream(dir, "main"); err != nil || upstream != "feature" {
+			t.Errorf("%s: got %q, %v, want feature", name, upstream, err)
+		}
+	}
+
+	cmd := exec.Command("git", "update-ref", "-d", "refs/remotes/origin/feature")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git update-ref: %s", out)
+	}
+	for name, backend := range map[string]Git{"exec": execGit{}, "go-git": goGit{}} {
+		if upstream, err := backend.Upstream(dir, "feature"); err == nil {
+			t.Errorf("%s: expected an error for a deleted upstream, got %q", name, upstream)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type pullRequestInfo struct {
+	Number            int        `json:"number"`
+	Title             string     `json:"title"`
+	URL               string     `json:"url"`
+	State             string     `json:"state"`
+	IsDraft           bool       `json:"isDraft"`
+	ReviewDecision    string     `json:"reviewDecision"`
+	Mergeable         string     `json:"mergeable"`
+	BaseRefName       string     `json:"baseRefName"`
+	HeadRefName       string     `json:"headRefName"`
+	StatusCheckRollup []checkRun `json:"statusCheckRollup"`
+}
+
+// check runs and commit statuses share the rollup list
+type checkRun struct {
+	Name       string `json:"name"`
+	Context    string `json:"context"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+	State      string `json:"state"`
+}
+
+const pullRequestFields = "number,title,url,state,isDraft,reviewDecision,mergeable,baseRefName,headRefName,statusCheckRollup"
+
+// list pull requests with head branch in any state, newest first
+func listPullRequestsIn(dir string, head string) ([]pullRequestInfo, error) {
+	out, err := runCommandIn(dir, "gh", "pr", "list", "--head", head, "--state", "all", "--limit", "20", "--json", pullRequestFields)
+	if err != nil {
+		return nil, fmt.Errorf("gh pr list failed: %s", gitErrorMessage(err))
+	}
+	pullRequests := []pullRequestInfo{}
+	if err := json.Unmarshal([]byte(out), &pullRequests); err != nil {
+		return nil, err
+	}
+	return pullRequests, nil
+}
+
+// most relevant pull request for a branch: the newest open one, else the newest
+func currentPullRequest(pullRequests []pullRequestInfo) (pullRequestInfo, bool) {
+	for _, pr := range pullRequests {
+		if pr.State == "OPEN" {
+			return pr, true
+		}
+	}
+	if len(pullRequests) > 0 {
+		return pullRequests[0], true
+	}
+	return pullRequestInfo{}, false
+}
+
+// open, draft, merged or closed
+func (pr pullRequestInfo) displayState() string {
+	if pr.State == "OPEN" && pr.IsDraft {
+		return "draft"
+	}
+	return strings.ToLower(pr.State)
+}
+
+func (pr pullRequestInfo) displayReview() string {
+	switch pr.ReviewDecision {
+	case "APPROVED":
+		return "approved"
+	case "CHANGES_REQUESTED":
+		return "changes requested"
+	case "REVIEW_REQUIRED":
+		return "review required"
+	}
+	return "-"
+}
+
+// passing, failing, pending or none
+func (pr pullRequestInfo) checksState() string {
+	if len(pr.StatusCheckRollup) == 0 {
+		return "none"
+	}
+	pending := false
+	for _, check := range pr.StatusCheckRollup {
+		result := check.Conclusion
+		if check.State != "" {
+			result = check.State
+		}
+		switch result {
+		case "FAILURE", "ERROR", "CANCELLED", "TIMED_OUT", "ACTION_REQUIRED", "STARTUP_FAILURE":
+			return "failing"
+		case "SUCCESS", "NEUTRAL", "SKIPPED":
+		default:
+			pending = true
+		}
+	}
+	if pending {
+		return "pending"
+	}
+	return "passing"
+}
+
+func (pr pullRequestInfo) displayMergeable() string {
+	switch pr.Mergeable {
+	case "MERGEABLE":
+		return "yes"
+	case "CONFLICTING":
+		return "conflicts"
+	}
+	return "unknown"
+}
+
+func mergePullRequestIn(dir string, number int, method string, deleteBranch bool) error {
+	args := []string{"pr", "merge", strconv.Itoa(number), "--" + method}
+	if deleteBranch {
+		args = append(args, "--delete-branch")
+	}
+	if _, err := runCommandIn(dir, "gh", args...); err != nil {
+		return fmt.Errorf("%s", gitErrorMessage(err))
+	}
+	return nil
+}
+
+// reasons a pull request can't be merged yet, none once it is merged
+func (pr pullRequestInfo) mergeBlockers() []string {
+	blockers := []string{}
+	if pr.State == "MERGED" {
+		return blockers
+	}
+	if pr.State != "OPEN" {
+		return []string{"not open (" + pr.displayState() + ")"}
+	}
+	if pr.IsDraft {
+		blockers = append(blockers, "draft")
+	}
+	switch pr.ReviewDecision {
+	case "APPROVED":
+	case "":
+		blockers = append(blockers, "not approved")
+	default:
+		blockers = append(blockers, pr.displayReview())
+	}
+	if checks := pr.checksState(); checks == "failing" || checks == "pending" {
+		blockers = append(blockers, "checks "+checks)
+	}
+	if pr.Mergeable != "MERGEABLE" {
+		blockers = append(blockers, "mergeable: "+pr.displayMergeable())
+	}
+	return blockers
+}
+
+// pull request from head into base that can be reused: the newest open one,
+// else the newest closed one. Merged pull requests are never reused.
+func existingPullRequest(pullRequests []pullRequestInfo, base string) (pullRequestInfo, bool) {
+	for _, state := range []string{"OPEN", "CLOSED"} {
+		for _, pr := range pullRequests {
+			if pr.BaseRefName == base && pr.State == state {
+				return pr, true
+			}
+		}
+	}
+	return pullRequestInfo{}, false
+}
+
+func reopenPullRequestIn(dir string, number int) error {
+	if _, err := runCommandIn(dir, "gh", "pr", "reopen", strconv.Itoa(number)); err != nil {
+		return fmt.Errorf("%s", gitErrorMessage(err))
+	}
+	return nil
+}
+
+// set title and body and add reviewers, labels and assignees
+func editPullRequestIn(dir string, number int, title string, body string, options pullRequestOptions) error {
+	args := []string{"pr", "edit", strconv.Itoa(number), "--title", title, "--body", body}
+	for _, reviewer := range options.reviewers {
+		args = append(args, "--add-reviewer", reviewer)
+	}
+	for _, label := range options.labels {
+		args = append(args, "--add-label", label)
+	}
+	for _, assignee := range options.assignees {
+		args = append(args, "--add-assignee", assignee)
+	}
+	if options.milestone != "" {
+		args = append(args, "--milestone", options.milestone)
+	}
+	if _, err := runCommandIn(dir, "gh", args...); err != nil {
+		return fmt.Errorf("%s", gitErrorMessage(err))
+	}
+	return nil
+}
+
+// owners from CODEOWNERS for files changed against base, or the default
+// owners when the diff isn't available
+func codeOwnersReviewers(dir string, base string) []string {
+	rules := readCodeOwners(dir)
+	if len(rules) == 0 {
+		return []string{}
+	}
+	changedFiles := []string{"*"}
+	if files, err := gitBackend.ChangedFiles(dir, base); err == nil && len(files) > 0 {
+		changedFiles = files
+	}
+
+	reviewers := []string{}
+	for _, file := range changedFiles {
+		// the last matching rule wins
+		owners := []string{}
+		for _, rule := range rules {
+			if file == "*" && rule.pattern == "*" || codeOwnersMatch(rule.pattern, file) {
+				owners = rule.owners
+			}
+		}
+		for _, owner := range owners {
+			if getIndex(reviewers, owner) == -1 {
+				reviewers = append(reviewers, owner)
+			}
+		}
+	}
+	return reviewers
+}
+
+type codeOwnersRule struct {
+	pattern string
+	owners  []string
+}
+
+func readCodeOwners(dir string) []codeOwnersRule {
+	for _, path := range []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"} {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			continue
+		}
+		rules := []codeOwnersRule{}
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			owners := []string{}
+			for _, owner := range fields[1:] {
+				if strings.HasPrefix(owner, "#") {
+					break
+				}
+				// users and teams, not emails
+				if strings.HasPrefix(owner, "@") {
+					owners = append(owners, strings.TrimPrefix(owner, "@"))
+				}
+			}
+			rules = append(rules, codeOwnersRule{pattern: fields[0], owners: owners})
+		}
+		return rules
+	}
+	return nil
+}
+
+// simplified gitignore-style matching used by CODEOWNERS
+func codeOwnersMatch(pattern string, file string) bool {
+	if pattern == "*" {
+		return true
+	}
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if strings.HasSuffix(pattern, "/") {
+		if anchored {
+			return strings.HasPrefix(file, pattern)
+		}
+		return strings.HasPrefix(file, pattern) || strings.Contains(file, "/"+pattern)
+	}
+	if !strings.Contains(pattern, "/") && !anchored {
+		matched, _ := filepath.Match(pattern, filepath.Base(file))
+		return matched
+	}
+	if matched, _ := filepath.Match(pattern, file); matched {
+		return true
+	}
+	return strings.HasPrefix(file, pattern+"/")
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeBlockers(t *testing.T) {
+	ready := pullRequestInfo{
+		State:             "OPEN",
+		ReviewDecision:    "APPROVED",
+		Mergeable:         "MERGEABLE",
+		StatusCheckRollup: []checkRun{{Name: "ci", Status: "COMPLETED", Conclusion: "SUCCESS"}},
+	}
+	tests := []struct {
+		name     string
+		change   func(pr *pullRequestInfo)
+		blockers []string
+	}{
+		{"ready", func(pr *pullRequestInfo) {}, []string{}},
+		{"merged", func(pr *pullRequestInfo) { pr.State = "MERGED"; pr.ReviewDecision = ""; pr.Mergeable = "UNKNOWN" }, []string{}},
+		{"closed", func(pr *pullRequestInfo) { pr.State = "CLOSED" }, []string{"not open (closed)"}},
+		{"draft", func(pr *pullRequestInfo) { pr.IsDraft = true }, []string{"draft"}},
+		{"no review decision", func(pr *pullRequestInfo) { pr.ReviewDecision = "" }, []string{"not approved"}},
+		{"changes requested", func(pr *pullRequestInfo) { pr.ReviewDecision = "CHANGES_REQUESTED" }, []string{"changes requested"}},
+		{"review required", func(pr *pullRequestInfo) { pr.ReviewDecision = "REVIEW_REQUIRED" }, []string{"review required"}},
+		{"checks failing", func(pr *pullRequestInfo) { pr.StatusCheckRollup[0].Conclusion = "FAILURE" }, []string{"checks failing"}},
+		{"checks pending", func(pr *pullRequestInfo) {
+			pr.StatusCheckRollup[0].Status = "IN_PROGRESS"
+			pr.StatusCheckRollup[0].Conclusion = ""
+		}, []string{"checks pending"}},
+		{"conflicts", func(pr *pullRequestInfo) { pr.Mergeable = "CONFLICTING" }, []string{"mergeable: conflicts"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pr := ready
+			pr.StatusCheckRollup = append([]checkRun{}, ready.StatusCheckRollup...)
+			test.change(&pr)
+			if blockers := pr.mergeBlockers(); !reflect.DeepEqual(blockers, test.blockers) {
+				t.Errorf("got %q, want %q", blockers, test.blockers)
+			}
+		})
+	}
+}
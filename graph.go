@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// depth of the given repositories and everything they depend on, where
+// repositories without dependencies have depth 0; fails on cycles among them
+func dependencyDepths(cfg Configuration, repos []string) (map[string]int, error) {
+	depths := map[string]int{}
+	visiting := map[string]bool{}
+	path := []string{}
+
+	var visit func(repo string) (int, error)
+	visit = func(repo string) (int, error) {
+		if depth, ok := depths[repo]; ok {
+			return depth, nil
+		}
+		if visiting[repo] {
+			start := getIndex(path, repo)
+			cycle := append(append([]string{}, path[start:]...), repo)
+			return 0, fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " → "))
+		}
+		visiting[repo] = true
+		path = append(path, repo)
+
+		depth := 0
+		for _, dependency := range cfg.Repositories[repo].DependsOn {
+			if _, ok := cfg.Repositories[dependency]; !ok {
+				continue
+			}
+			dependencyDepth, err := visit(dependency)
+			if err != nil {
+				return 0, err
+			}
+			if dependencyDepth+1 > depth {
+				depth = dependencyDepth + 1
+			}
+		}
+
+		path = path[:len(path)-1]
+		visiting[repo] = false
+		depths[repo] = depth
+		return depth, nil
+	}
+
+	for _, repo := range repos {
+		if _, err := visit(repo); err != nil {
+			return nil, err
+		}
+	}
+	return depths, nil
+}
+
+// group indices of states into layers that only depend on earlier layers;
+// indices within a layer keep their tree order
+func dependencyLayerIndices(cfg Configuration, states []State) ([][]int, error) {
+	// only the graph reachable from these states, so a cycle elsewhere
+	// doesn't stop them
+	repos := []string{}
+	for _, state := range states {
+		repos = append(repos, state.Repo)
+	}
+	depths, err := dependencyDepths(cfg, repos)
+	if err != nil {
+		return nil, err
+	}
+	byDepth := map[int][]int{}
+	for i, state := range states {
+		depth := depths[state.Repo]
+		byDepth[depth] = append(byDepth[depth], i)
+	}
+	levels := []int{}
+	for depth := range byDepth {
+		levels = append(levels, depth)
+	}
+	sort.Ints(levels)
+
+	layers := [][]int{}
+	for _, depth := range levels {
+		layers = append(layers, byDepth[depth])
+	}
+	return layers, nil
+}
+
+// group states into layers that only depend on earlier layers; states
+// within a layer keep their tree order
+func dependencyLayers(cfg Configuration, states []State) ([][]State, error) {
+	indexLayers, err := dependencyLayerIndices(cfg, states)
+	if err != nil {
+		return nil, err
+	}
+	layers := [][]State{}
+	for _, indices := range indexLayers {
+		layer := []State{}
+		for _, i := range indices {
+			layer = append(layer, states[i])
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// states flattened into dependency order
+func dependencyOrder(cfg Configuration, states []State) ([]State, error) {
+	layers, err := dependencyLayers(cfg, states)
+	if err != nil {
+		return nil, err
+	}
+	ordered := []State{}
+	for _, layer := range layers {
+		ordered = append(ordered, layer...)
+	}
+	return ordered, nil
+}
+
+// like runAcrossStates, but one dependency layer at a time; errors are
+// returned in the order of the given states
+func runInDependencyOrder(cfg Configuration, states []State, parallel int, failFast bool, fn func(ctx context.Context, i int, state State) error) ([]error, error) {
+	layers, err := dependencyLayerIndices(cfg, states)
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(states))
+
+	failed := false
+	for _, indices := range layers {
+		if failed && failFast {
+			for _, i := range indices {
+				errs[i] = context.Canceled
+			}
+			continue
+		}
+		layer := []State{}
+		for _, i := range indices {
+			layer = append(layer, states[i])
+		}
+		layerErrs := runAcrossStates(layer, parallel, failFast, func(ctx context.Context, j int, state State) error {
+			return fn(ctx, indices[j], state)
+		})
+		for j, err := range layerErrs {
+			errs[indices[j]] = err
+			if err != nil {
+				failed = true
+			}
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestDependencyLayers(t *testing.T) {
+	config := Configuration{Repositories: map[string]Repository{
+		"acme/api":  {DependsOn: []string{"acme/lib"}},
+		"acme/lib":  {DependsOn: []string{"acme/core"}},
+		"acme/core": {},
+		"acme/a":    {DependsOn: []string{"acme/b"}},
+		"acme/b":    {DependsOn: []string{"acme/a"}},
+	}}
+	tests := []struct {
+		name   string
+		repos  []string
+		layers [][]string
+		err    string
+	}{
+		{"dependencies first", []string{"acme/api", "acme/lib"}, [][]string{{"acme/lib"}, {"acme/api"}}, ""},
+		{"through repositories outside the tree", []string{"acme/api", "acme/core"}, [][]string{{"acme/core"}, {"acme/api"}}, ""},
+		{"cycle in the tree", []string{"acme/api", "acme/a"}, nil, "dependency cycle: acme/a → acme/b → acme/a"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			states := []State{}
+			for _, repo := range test.repos {
+				states = append(states, State{Repo: repo})
+			}
+			layers, err := dependencyLayers(config, states)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := [][]string{}
+			for _, layer := range layers {
+				repos := []string{}
+				for _, state := range layer {
+					repos = append(repos, state.Repo)
+				}
+				got = append(got, repos)
+			}
+			if !reflect.DeepEqual(got, test.layers) {
+				t.Errorf("got %v, want %v", got, test.layers)
+			}
+		})
+	}
+}
+
+func TestRunInDependencyOrderDuplicateStates(t *testing.T) {
+	config := Configuration{Repositories: map[string]Repository{"acme/lib": {}}}
+	states := []State{{Repo: "acme/lib", Branch: "main"}, {Repo: "acme/lib", Branch: "main"}}
+
+	seen := make([]bool, len(states))
+	_, err := runInDependencyOrder(config, states, 1, false, func(ctx context.Context, i int, state State) error {
+		seen[i] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !seen[0] || !seen[1] {
+		t.Errorf("got %v, want every state run", seen)
+	}
+}
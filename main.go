@@ -1,29 +1,63 @@
 package main
 
 import (
+	"fmt"
 	"github.com/urfave/cli/v2"
 	"os"
+	"time"
 )
 
 func main() {
 
 	// load local config
 	config := loadConfigToml()
+	backend, err := newGitBackend(config.GitBackend)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	gitBackend = backend
 
 	// run CLI app
 	app := &cli.App{
-		Name:  "\u001b[31;1mbsync\u001b[0m",
-		Usage: "Sync branches across multiple git repositories",
+		Name:   "bsync",
+		Usage:  "Sync branches across multiple git repositories",
+		Before: setupOutput,
+
+		EnableBashCompletion: true,
 		Commands: []*cli.Command{
 			{
-				Name:   "load",
-				Usage:  "load tree and pull branches",
-				Action: loadCmdAction(config),
+				Name:         "load",
+				Usage:        "load tree and pull branches",
+				Action:       loadCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "order of trees in the picker: alphabetical, recent or owner",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 4,
+						Usage: "number of repositories to load at once within a dependency layer",
+					},
+				},
 			}, {
-				Name:    "add",
-				Aliases: []string{"add-repo"},
-				Usage:   "add repository to local config",
-				Action:  addCmdAction(config),
+				Name:         "add",
+				Aliases:      []string{"add-repo"},
+				Usage:        "add repository to local config",
+				Action:       addCmdAction(config),
+				BashComplete: completer(config, nil),
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "depends-on",
+						Usage: "`repository` this repository depends on, can be repeated",
+					},
+					&cli.StringFlag{
+						Name:  "default-branch",
+						Usage: "default branch of the repository (default: detected from origin/HEAD)",
+					},
+				},
 			}, {
 				Name:    "new",
 				Aliases: []string{"new-tree"},
@@ -40,21 +74,402 @@ func main() {
 				Usage:   "list trees",
 				Action:  listCmdAction(config),
 			}, {
-				Name:    "rm",
-				Aliases: []string{"remove", "delete"},
-				Usage:   "remove tree",
-				Action:  removeCmdAction(config),
+				Name:         "rm",
+				Aliases:      []string{"remove", "delete"},
+				Usage:        "remove tree",
+				Action:       removeCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
 			}, {
-				Name:    "pr",
-				Aliases: []string{"pull-request"},
-				Usage:   "open pull requests for current branch",
-				Action:  pullRequestCmdAction(config),
+				Name:         "pr",
+				Aliases:      []string{"pull-request"},
+				Usage:        "open pull requests for current branch",
+				Action:       pullRequestCmdAction(config),
+				BashComplete: completer(config, branchCandidates),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "draft",
+						Usage: "open as draft",
+					},
+					&cli.StringSliceFlag{
+						Name:  "reviewer",
+						Usage: "request review from user or team",
+					},
+					&cli.StringSliceFlag{
+						Name:  "label",
+						Usage: "add label",
+					},
+					&cli.StringSliceFlag{
+						Name:  "assignee",
+						Usage: "assign user",
+					},
+					&cli.StringFlag{
+						Name:  "milestone",
+						Usage: "add to milestone",
+					},
+					&cli.BoolFlag{
+						Name:  "no-codeowners",
+						Usage: "don't request reviews from CODEOWNERS",
+					},
+					&cli.BoolFlag{
+						Name:  "update",
+						Usage: "update or reopen existing pull requests without asking",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-existing",
+						Usage: "leave existing pull requests untouched",
+					},
+					&cli.BoolFlag{
+						Name:  "push",
+						Usage: "push the branch without asking when it isn't up to date on origin",
+					},
+					&cli.BoolFlag{
+						Name:  "preview",
+						Usage: "print the pull request titles without opening anything",
+					},
+					&cli.BoolFlag{
+						Name:  "edit",
+						Usage: "revise the generated body in $EDITOR before submitting",
+					},
+					&cli.BoolFlag{
+						Name:  "open",
+						Usage: "open the pull requests in the browser",
+					},
+					&cli.BoolFlag{
+						Name:  "copy",
+						Usage: "copy the pull request urls to the clipboard",
+					},
+				},
+			},
+			{
+				Name:         "branch",
+				Aliases:      []string{"switch-branch"},
+				Usage:        "switch to branch in tree",
+				Action:       branchCmdAction(config),
+				BashComplete: completer(config, branchCandidates),
+				Subcommands: []*cli.Command{
+					{
+						Name:         "new",
+						Usage:        "create a branch named by the branch policy",
+						Action:       branchNewCmdAction(config),
+						BashComplete: completer(config, nil),
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "type",
+								Usage: "branch type, e.g. feature or fix",
+							},
+							&cli.StringFlag{
+								Name:  "ticket",
+								Usage: "ticket key, e.g. ABC-123",
+							},
+							&cli.StringFlag{
+								Name:  "description",
+								Usage: "short description used for the slug",
+							},
+							&cli.StringFlag{
+								Name:  "from",
+								Usage: "`branch` or commit to start from (default: the repository's default branch)",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:         "mv",
+				Aliases:      []string{"rename"},
+				Usage:        "rename tree",
+				ArgsUsage:    "<old> <new>",
+				Action:       moveCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
 			},
 			{
-				Name:    "branch",
-				Aliases: []string{"switch-branch"},
-				Usage:   "switch to branch in tree",
-				Action: branchCmdAction(config),
+				Name:         "cp",
+				Aliases:      []string{"copy"},
+				Usage:        "copy tree",
+				ArgsUsage:    "<source> <destination>",
+				Action:       copyCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+			},
+			{
+				Name:         "merge",
+				Usage:        "merge two trees into one",
+				ArgsUsage:    "<a> <b> --into <c>",
+				Action:       mergeCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "into",
+						Usage: "name of the merged `tree`",
+					},
+					&cli.StringFlag{
+						Name:  "prefer",
+						Usage: "`tree` whose branch wins when both pin the same repository",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "overwrite the destination tree if it exists",
+					},
+				},
+			},
+			{
+				Name:         "exec",
+				Usage:        "run a command in every repository of a tree",
+				ArgsUsage:    "-- <command...>",
+				Action:       execCmdAction(config),
+				BashComplete: completer(config, nil),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "tree",
+						Usage: "`tree` to run in (default: active tree)",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 1,
+						Usage: "number of repositories to run in at once",
+					},
+					&cli.BoolFlag{
+						Name:  "fail-fast",
+						Usage: "stop after the first failing repository",
+					},
+					&cli.BoolFlag{
+						Name:  "group",
+						Usage: "group output per repository instead of prefixing lines",
+					},
+					&cli.BoolFlag{
+						Name:  "shell",
+						Usage: "run the command through sh -c",
+					},
+				},
+			},
+			{
+				Name:         "sync",
+				Usage:        "fetch and fast-forward every branch in a tree",
+				ArgsUsage:    "[tree]",
+				Action:       syncCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 4,
+						Usage: "number of repositories to fetch at once",
+					},
+					&cli.StringFlag{
+						Name:  "strategy",
+						Usage: "how to integrate diverged branches: rebase, merge or skip (default: ask)",
+					},
+				},
+			},
+			{
+				Name:         "push",
+				Usage:        "push every branch in a tree to origin",
+				ArgsUsage:    "[tree]",
+				Action:       pushCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 4,
+						Usage: "number of repositories to push at once",
+					},
+					&cli.BoolFlag{
+						Name:  "force-with-lease",
+						Usage: "force push unless the remote branch changed since the last fetch",
+					},
+				},
+			},
+			{
+				Name:         "status",
+				Aliases:      []string{"st"},
+				Usage:        "show branch and working tree status of a tree",
+				ArgsUsage:    "[tree]",
+				Action:       statusCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+			},
+			{
+				Name:         "prs",
+				Usage:        "show pull request status for every branch in a tree",
+				ArgsUsage:    "[tree]",
+				Action:       prsCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "watch",
+						Usage: "refresh periodically",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Value: 30 * time.Second,
+						Usage: "refresh interval in watch mode",
+					},
+				},
+			},
+			{
+				Name:         "merge-prs",
+				Usage:        "merge the pull requests of a tree once all are approved and green",
+				ArgsUsage:    "<tree>",
+				Action:       mergePullRequestsCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "order",
+						Usage: "`repository` to merge first, repeat to set the order (default: tree merge_order, then dependency order)",
+					},
+					&cli.StringFlag{
+						Name:  "method",
+						Value: "merge",
+						Usage: "merge method: merge, squash or rebase",
+					},
+					&cli.BoolFlag{
+						Name:  "delete-branch",
+						Usage: "delete branches after merging",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "check pull requests and show the merge order without merging",
+					},
+				},
+			},
+			{
+				Name:         "reset-tree",
+				Usage:        "check out the default branch in every repository of a tree",
+				ArgsUsage:    "[tree]",
+				Action:       resetTreeCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 4,
+						Usage: "number of repositories to check out at once",
+					},
+				},
+			},
+			{
+				Name:         "home",
+				Usage:        "check out and pull the default branch in every repository",
+				ArgsUsage:    "[tree]",
+				Action:       homeCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 4,
+						Usage: "number of repositories to update at once within a dependency layer",
+					},
+				},
+			},
+			{
+				Name:   "prune",
+				Usage:  "delete stale local branches across all repositories",
+				Action: pruneCmdAction(config),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "older-than",
+						Usage: "also list branches without commits for this many days",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "list stale branches without deleting",
+					},
+					&cli.BoolFlag{
+						Name:  "yes",
+						Usage: "delete all listed branches without asking",
+					},
+					&cli.BoolFlag{
+						Name:  "force",
+						Usage: "also delete branches used by trees",
+					},
+				},
+			},
+			{
+				Name:         "graph",
+				Usage:        "print repository dependency graph",
+				ArgsUsage:    "[tree]",
+				Action:       graphCmdAction(config),
+				BashComplete: completer(config, treeCandidates),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dot",
+						Usage: "print as Graphviz DOT",
+					},
+				},
+			},
+			{
+				Name:         "ui",
+				Aliases:      []string{"dashboard"},
+				Usage:        "open tree dashboard",
+				Action:       uiCmdAction(config),
+				BashComplete: completer(config, nil),
+			},
+			{
+				Name:   "prompt",
+				Usage:  "print active tree and branch status for shell prompts",
+				Action: promptCmdAction(config),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "🌳{tree} {status}",
+						Usage: "template using {tree}, {repo}, {branch}, {current} and {status}",
+					},
+				},
+			},
+			{
+				Name:  "hooks",
+				Usage: "manage git hooks that keep trees up to date",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "install",
+						Usage:  "install post-checkout hook in every registered repository",
+						Action: hooksInstallCmdAction(config),
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "auto",
+								Usage: "update the active tree without asking",
+							},
+						},
+					},
+					{
+						Name:   "uninstall",
+						Usage:  "remove bsync hooks from every registered repository",
+						Action: hooksUninstallCmdAction(config),
+					},
+					{
+						Name:   "post-checkout",
+						Hidden: true,
+						Action: hooksPostCheckoutCmdAction(config),
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name: "auto",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:         "completion",
+				Usage:        "print shell completion script",
+				ArgsUsage:    "bash|zsh|fish",
+				Action:       completionCmdAction(config),
+				BashComplete: completer(config, shellCandidates),
+			},
+			{
+				Name:         "doctor",
+				Usage:        "validate repositories and trees and offer fixes",
+				Action:       doctorCmdAction(config),
+				BashComplete: completer(config, nil),
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "apply all available fixes without asking",
+					},
+					&cli.StringSliceFlag{
+						Name:  "search",
+						Usage: "directory to search for moved clones (default: home directory)",
+					},
+					&cli.IntFlag{
+						Name:  "depth",
+						Value: 4,
+						Usage: "maximum directory depth when searching for moved clones",
+					},
+				},
 			},
 		},
 		Flags: []cli.Flag{
@@ -63,6 +478,15 @@ func main() {
 				Value: "",
 				Usage: "name of tree",
 			},
+			&cli.StringFlag{
+				Name:  "output",
+				Value: "text",
+				Usage: "output format: text or json",
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "disable colors (also honours NO_COLOR)",
+			},
 		},
 	}
 
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// generates branch names from a type, ticket and description
+const defaultBranchTemplate = "{type}/{ticket}-{slug}"
+
+var defaultBranchTypes = []string{"feature", "fix", "chore", "docs", "refactor", "test"}
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+var repeatedSeparators = regexp.MustCompile(`([/_-])[/_-]+`)
+
+func (policy BranchPolicy) template() string {
+	if policy.Template == "" {
+		return defaultBranchTemplate
+	}
+	return policy.Template
+}
+
+func (policy BranchPolicy) types() []string {
+	if len(policy.Types) == 0 {
+		return defaultBranchTypes
+	}
+	return policy.Types
+}
+
+// lowercase words joined by dashes
+func slugify(text string) string {
+	slug := slugSeparators.ReplaceAllString(strings.ToLower(text), "-")
+	return strings.Trim(slug, "-")
+}
+
+// fill the branch template, dropping separators left over by an empty ticket
+func generateBranchName(policy BranchPolicy, branchType string, ticket string, description string) string {
+	name := strings.NewReplacer(
+		"{type}", branchType,
+		"{ticket}", strings.ToUpper(strings.TrimSpace(ticket)),
+		"{slug}", slugify(description),
+	).Replace(policy.template())
+	name = repeatedSeparators.ReplaceAllString(name, "$1")
+	return strings.Trim(name, "/_-")
+}
+
+// error when policies are configured and the branch matches none of them
+func checkBranchPolicy(policy BranchPolicy, branch string) error {
+	if len(policy.Patterns) == 0 || getIndex(policy.Exempt, branch) != -1 {
+		return nil
+	}
+	for _, pattern := range policy.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid branch policy pattern %q: %s", pattern, err.Error())
+		}
+		if re.MatchString(branch) {
+			return nil
+		}
+	}
+	return fmt.Errorf("branch %s does not match the naming policy (%s)", branch, strings.Join(policy.Patterns, ", "))
+}
+
+// print a warning, or return an error when the policy refuses non-conforming branches
+func enforceBranchPolicy(policy BranchPolicy, branch string) error {
+	err := checkBranchPolicy(policy, branch)
+	if err == nil {
+		return nil
+	}
+	if policy.Enforce == "refuse" {
+		return err
+	}
+	fmt.Fprintln(progressWriter(), "Warning:", err.Error())
+	return nil
+}
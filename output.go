@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+	"github.com/urfave/cli/v2"
+	"io"
+	"os"
+	"strings"
+)
+
+type outputSettings struct {
+	json  bool
+	color bool
+	tty   bool
+}
+
+var output = outputSettings{color: true, tty: true}
+
+var icons = map[string][2]string{
+	"ok":   {"✅", "[ok]"},
+	"fail": {"❌", "[failed]"},
+	"skip": {"⏭", "[skipped]"},
+	"tree": {"🌳", ""},
+	"log":  {"🪵", ""},
+}
+
+// detect terminal and apply --output and --no-color before any command runs
+func setupOutput(cCtx *cli.Context) error {
+	switch cCtx.String("output") {
+	case "", "text":
+	case "json":
+		output.json = true
+	default:
+		return cli.Exit("--output must be text or json", 1)
+	}
+
+	output.tty = isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+	_, noColor := os.LookupEnv("NO_COLOR")
+	output.color = output.tty && !noColor && !output.json && !cCtx.Bool("no-color") && os.Getenv("TERM") != "dumb"
+
+	if !output.color {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		disableStyles()
+	}
+	return nil
+}
+
+// emoji on a terminal, a plain marker otherwise
+func icon(name string) string {
+	if output.tty && !output.json {
+		return icons[name][0]
+	}
+	return icons[name][1]
+}
+
+// join with spaces, skipping empty parts such as icons without a text marker
+func joinNonEmpty(parts ...string) string {
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, " ")
+}
+
+// where progress and prompts go, keeping stdout clean for json
+func progressWriter() io.Writer {
+	if output.json {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// print progress text, unless results are printed as json
+func progressf(format string, a ...interface{}) {
+	fmt.Fprintf(progressWriter(), format, a...)
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// result of an operation on one state, as printed with --output json
+type stateResultJSON struct {
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	Status string `json:"status"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	Ahead  int    `json:"ahead,omitempty"`
+	Behind int    `json:"behind,omitempty"`
+}
+
+func newStateResultJSON(state State, outcome string, skipped bool, err error, ahead int, behind int) stateResultJSON {
+	result := stateResultJSON{
+		Repo:   state.Repo,
+		Branch: state.Branch,
+		Status: "ok",
+		Result: outcome,
+		Ahead:  ahead,
+		Behind: behind,
+	}
+	if skipped {
+		result.Status = "skipped"
+	}
+	if err != nil {
+		result.Status = "failed"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// outcome with an icon for tables
+func formatOutcome(outcome string, skipped bool, err error) string {
+	if err != nil {
+		return icon("fail") + " " + err.Error()
+	}
+	if skipped {
+		return icon("skip") + " " + outcome
+	}
+	return icon("ok") + " " + outcome
+}
+
+type treeResultsJSON struct {
+	Tree    string            `json:"tree"`
+	Results []stateResultJSON `json:"results"`
+}
+
+type pullRequestJSON struct {
+	Base   string `json:"base"`
+	Head   string `json:"head"`
+	Title  string `json:"title"`
+	Result string `json:"result"`
+	URL    string `json:"url,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// clear the terminal before redrawing
+func clearScreen() {
+	if output.tty && !output.json {
+		fmt.Print("\033[H\033[2J")
+	}
+}
@@ -17,4 +17,53 @@ var newTreeStyle = lipgloss.NewStyle().
 
 var enterTextStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.Color("#7dc088")).
-	PaddingTop(1)
\ No newline at end of file
+	PaddingTop(1)
+var repoPrefixStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#EF4160"))
+
+var repoHeaderStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("#EF4160"))
+
+var paneStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("#555555")).
+	Padding(0, 1)
+
+var focusedPaneStyle = paneStyle.Copy().
+	BorderForeground(lipgloss.Color("#EF4160"))
+
+var helpStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#EF4160"))
+
+var mutedStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#888888"))
+
+var highlightStyle = lipgloss.NewStyle().
+	Bold(true).
+	Foreground(lipgloss.Color("#EF4160"))
+
+var titleStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#4a90d9"))
+
+// strip colors and text attributes, keeping layout
+func plainStyle(style lipgloss.Style) lipgloss.Style {
+	return style.Copy().
+		UnsetBold().
+		UnsetForeground().
+		UnsetBackground().
+		UnsetBorderForeground()
+}
+
+func disableStyles() {
+	newTreeStyle = plainStyle(newTreeStyle)
+	enterTextStyle = plainStyle(enterTextStyle)
+	repoPrefixStyle = plainStyle(repoPrefixStyle)
+	repoHeaderStyle = plainStyle(repoHeaderStyle)
+	paneStyle = plainStyle(paneStyle)
+	focusedPaneStyle = plainStyle(focusedPaneStyle)
+	helpStyle = plainStyle(helpStyle)
+	mutedStyle = plainStyle(mutedStyle)
+	highlightStyle = plainStyle(highlightStyle)
+	titleStyle = plainStyle(titleStyle)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// turns a branch name into a pull request title
+const defaultTitleFormat = "[{base}] {title}"
+
+// used when the configuration has no title rules: "a-b--c" becomes
+// "a b -- c". Double dashes are held aside while single dashes become
+// spaces, so any character may surround a dash.
+var defaultTitleRules = []TitleRule{
+	{Pattern: `--`, Replace: "\x00"},
+	{Pattern: `-`, Replace: " "},
+	{Pattern: `\x00`, Replace: " -- "},
+}
+
+type compiledTitleRule struct {
+	rule   TitleRule
+	regexp *regexp.Regexp
+}
+
+func compileTitleRules(rules []TitleRule) ([]compiledTitleRule, error) {
+	if len(rules) == 0 {
+		rules = defaultTitleRules
+	}
+	compiled := []compiledTitleRule{}
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("title rule %d: invalid pattern %q: %s", i+1, rule.Pattern, err.Error())
+		}
+		switch rule.Case {
+		case "", "upper", "lower", "title":
+		default:
+			return nil, fmt.Errorf("title rule %d: case must be upper, lower or title", i+1)
+		}
+		compiled = append(compiled, compiledTitleRule{rule: rule, regexp: re})
+	}
+	return compiled, nil
+}
+
+// apply every rule in order to the whole title. The case of a rule is
+// applied to its expanded replacement, so `^\w` → `$0` with case upper
+// capitalizes the title.
+func applyTitleRules(rules []compiledTitleRule, title string) string {
+	for _, rule := range rules {
+		result := []byte{}
+		last := 0
+		for _, match := range rule.regexp.FindAllStringSubmatchIndex(title, -1) {
+			result = append(result, title[last:match[0]]...)
+			replacement := rule.regexp.ExpandString(nil, rule.rule.Replace, title, match)
+			result = append(result, changeCase(string(replacement), rule.rule.Case)...)
+			last = match[1]
+		}
+		title = string(append(result, title[last:]...))
+	}
+	return strings.TrimSpace(title)
+}
+
+func changeCase(text string, textCase string) string {
+	switch textCase {
+	case "upper":
+		return strings.ToUpper(text)
+	case "lower":
+		return strings.ToLower(text)
+	case "title":
+		words := strings.Fields(text)
+		for i, word := range words {
+			runes := []rune(word)
+			words[i] = strings.ToUpper(string(runes[0])) + string(runes[1:])
+		}
+		return strings.Join(words, " ")
+	}
+	return text
+}
+
+func formatPRTitle(config Configuration, currentBranch string, destinationBranch string) (string, error) {
+	rules, err := compileTitleRules(config.TitleRules)
+	if err != nil {
+		return "", err
+	}
+	format := config.TitleFormat
+	if format == "" {
+		format = defaultTitleFormat
+	}
+	replacer := strings.NewReplacer(
+		"{base}", destinationBranch,
+		"{branch}", currentBranch,
+		"{title}", applyTitleRules(rules, currentBranch),
+	)
+	return replacer.Replace(format), nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func TestCompileTitleRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []TitleRule
+		count int
+		err   string
+	}{
+		{"defaults", nil, len(defaultTitleRules), ""},
+		{"configured", []TitleRule{{Pattern: `_`, Replace: " "}}, 1, ""},
+		{"case", []TitleRule{{Pattern: `^\w`, Replace: "$0", Case: "upper"}}, 1, ""},
+		{"invalid pattern", []TitleRule{{Pattern: `_`}, {Pattern: `(`}}, 0, "title rule 2: invalid pattern \"(\": error parsing regexp: missing closing ): `(`"},
+		{"invalid case", []TitleRule{{Pattern: `_`, Case: "camel"}}, 0, "title rule 1: case must be upper, lower or title"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compiled, err := compileTitleRules(test.rules)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(compiled) != test.count {
+				t.Fatalf("got %d rules, want %d", len(compiled), test.count)
+			}
+		})
+	}
+}
+
+func TestApplyTitleRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []TitleRule
+		branch string
+		title  string
+	}{
+		{"single dashes", nil, "fix-login-form", "fix login form"},
+		{"double dash", nil, "api--fix-login", "api -- fix login"},
+		{"non-ascii letters", nil, "café-menu", "café menu"},
+		{"dash before punctuation", nil, "fix-.env", "fix .env"},
+		{"triple dash", nil, "a---b", "a --  b"},
+		{"surrounding spaces trimmed", nil, "-wip-", "wip"},
+		{"configured rules replace defaults", []TitleRule{{Pattern: `_`, Replace: " "}}, "fix_login-form", "fix login-form"},
+		{"capture groups", []TitleRule{{Pattern: `^(\w+)/`, Replace: "$1: "}}, "feat/login", "feat: login"},
+		{"upper case", []TitleRule{{Pattern: `^[a-z]+-\d+`, Replace: "$0", Case: "upper"}}, "abc-12-fix", "ABC-12-fix"},
+		{"title case", []TitleRule{{Pattern: `-`, Replace: " "}, {Pattern: `.*`, Replace: "$0", Case: "title"}}, "fix-login", "Fix Login"},
+		{"lower case", []TitleRule{{Pattern: `.*`, Replace: "$0", Case: "lower"}}, "Fix-Login", "fix-login"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := compileTitleRules(test.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if title := applyTitleRules(rules, test.branch); title != test.title {
+				t.Errorf("applyTitleRules(%q) = %q, want %q", test.branch, title, test.title)
+			}
+		})
+	}
+}
+
+func TestFormatPRTitle(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		title  string
+	}{
+		{"default format", "", "[main] fix login"},
+		{"configured format", "{title} ({branch} into {base})", "fix login (fix-login into main)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			title, err := formatPRTitle(Configuration{TitleFormat: test.format}, "fix-login", "main")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if title != test.title {
+				t.Errorf("got %q, want %q", title, test.title)
+			}
+		})
+	}
+}
@@ -3,29 +3,113 @@ package main
 import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
+	"sort"
 )
 
 // define initial model
-func singleSelectionInitialModel(cfg Configuration) selectionModel {
-	treeChoices := []string{}
-	for tree := range cfg.Trees {
-		treeChoices = append(treeChoices, tree)
-	}
+func singleSelectionInitialModel(cfg Configuration, sortBy string) selectionModel {
+	treeChoices := sortTreeNames(cfg, sortBy)
 	return selectionModel{
 		choices:     treeChoices,
 		selected:    make(map[int]struct{}),
 		multiSelect: false,
 		quit:        false,
+		sortedBy:    sortBy,
+		previews:    treePreviews(cfg),
+		filtered:    allIndices(treeChoices),
 	}
 }
 
+func treePreviews(cfg Configuration) map[string][]State {
+	previews := map[string][]State{}
+	for name, tree := range cfg.Trees {
+		previews[name] = tree.States
+	}
+	return previews
+}
+
+func allIndices(choices []string) []int {
+	indices := make([]int, len(choices))
+	for i := range choices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// choice under the cursor, or "" if nothing matches
+func (m selectionModel) current() string {
+	if len(m.filtered) == 0 {
+		return ""
+	}
+	return m.choices[m.filtered[m.cursor]]
+}
+
+// narrow choices to those matching the filter, best match first
+func (m selectionModel) applyFilter() selectionModel {
+	type match struct {
+		index int
+		score int
+	}
+	matches := []match{}
+	for i, choice := range m.choices {
+		if score, ok := fuzzyScore(m.filter, choice); ok {
+			matches = append(matches, match{index: i, score: score})
+		}
+	}
+	if m.filter != "" {
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].score > matches[j].score
+		})
+	}
+	m.filtered = []int{}
+	for _, match := range matches {
+		m.filtered = append(m.filtered, match.index)
+	}
+	m.cursor = 0
+	return m
+}
+
+// pick a tree interactively, returns "" if the user quits
+func selectTree(cfg Configuration) (string, error) {
+	if err := checkTreeSortOrder(cfg.PickerSort); err != nil {
+		return "", err
+	}
+	p := tea.NewProgram(singleSelectionInitialModel(cfg, cfg.PickerSort))
+	finalModel, err := p.StartReturningModel()
+	if err != nil {
+		return "", err
+	}
+	if m, ok := finalModel.(selectionModel); ok && !m.quit {
+		return m.current(), nil
+	}
+	return "", nil
+}
+
+// resolve tree from argument, falling back to the active tree and then the picker
+func resolveTreeName(cfg Configuration, name string) (string, error) {
+	if name == "" {
+		name = cfg.ActiveTree
+	}
+	if name == "" {
+		selected, err := selectTree(cfg)
+		if err != nil {
+			return "", err
+		}
+		name = selected
+	}
+	if name == "" {
+		return "", fmt.Errorf("no tree selected")
+	}
+	if _, ok := cfg.Trees[name]; !ok {
+		return "", fmt.Errorf("tree %s does not exist", name)
+	}
+	return name, nil
+}
+
 func multiTreeAssignInitialModel(cfg Configuration, proposedState State) selectionModel {
 
 	// all trees
-	treeChoices := []string{}
-	for tree := range cfg.Trees {
-		treeChoices = append(treeChoices, tree)
-	}
+	treeChoices := sortTreeNames(cfg, cfg.PickerSort)
 
 	// trees where branch is already linked
 	linkedTrees := make(map[int]struct{})
@@ -46,6 +130,9 @@ func multiTreeAssignInitialModel(cfg Configuration, proposedState State) selecti
 		selected:    linkedTrees,
 		multiSelect: true,
 		quit:        false,
+		sortedBy:    cfg.PickerSort,
+		previews:    treePreviews(cfg),
+		filtered:    allIndices(treeChoices),
 	}
 }
 
@@ -57,51 +144,157 @@ func (m selectionModel) Init() tea.Cmd {
 // update model
 func (m selectionModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
 	case tea.KeyMsg:
+		if m.filtering {
+			return m.updateFilter(msg)
+		}
 		switch msg.String() {
 		case "ctrl+c", "q":
 			// clear selection
 			m.quit = true
 			m.selected = make(map[int]struct{})
 			return m, tea.Quit
+		case "/":
+			m.filtering = true
+		case "esc":
+			m.filter = ""
+			m = m.applyFilter()
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
 			}
 		case "down", "j":
-			if m.cursor < len(m.choices)-1 {
+			if m.cursor < len(m.filtered)-1 {
 				m.cursor++
 			}
+		case "pgup":
+			m.cursor -= m.pageSize()
+			if m.cursor < 0 {
+				m.cursor = 0
+			}
+		case "pgdown":
+			m.cursor += m.pageSize()
+			if m.cursor > len(m.filtered)-1 {
+				m.cursor = len(m.filtered) - 1
+			}
+			if m.cursor < 0 {
+				m.cursor = 0
+			}
 		case "s":
 			if m.multiSelect {
 				return m, tea.Quit
 			}
 			fmt.Println("\nYour selected choices:")
 		case " ":
-			_, ok := m.selected[m.cursor]
+			if len(m.filtered) == 0 {
+				return m, nil
+			}
+			index := m.filtered[m.cursor]
+			_, ok := m.selected[index]
 			if m.multiSelect {
 				if ok {
-					delete(m.selected, m.cursor)
+					delete(m.selected, index)
 				} else {
-					m.selected[m.cursor] = struct{}{}
+					m.selected[index] = struct{}{}
 				}
 			}
 		case "enter":
+			if !m.multiSelect && len(m.filtered) == 0 {
+				return m, nil
+			}
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m selectionModel) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quit = true
+		m.selected = make(map[int]struct{})
+		return m, tea.Quit
+	case tea.KeyEsc:
+		m.filtering = false
+		m.filter = ""
+		return m.applyFilter(), nil
+	case tea.KeyEnter:
+		m.filtering = false
+		if !m.multiSelect && len(m.filtered) > 0 {
 			return m, tea.Quit
 		}
+	case tea.KeyUp:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case tea.KeyDown:
+		if m.cursor < len(m.filtered)-1 {
+			m.cursor++
+		}
+	case tea.KeyBackspace:
+		if len(m.filter) > 0 {
+			runes := []rune(m.filter)
+			m.filter = string(runes[:len(runes)-1])
+			return m.applyFilter(), nil
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.filter += string(msg.Runes)
+		return m.applyFilter(), nil
 	}
 	return m, nil
 }
 
+// number of choices shown at once, leaving room for header, preview and help
+func (m selectionModel) pageSize() int {
+	if m.height <= 0 {
+		return 10
+	}
+	size := m.height - 16
+	if size < 3 {
+		size = 3
+	}
+	return size
+}
+
 func (m selectionModel) View() string {
 	// The header
-	s := "Select a tree to load:\n\n"
+	s := "Select a tree to load:"
 	if m.multiSelect {
-		s = "Select/unselect the trees to link with this branch:\n\n"
+		s = "Select/unselect the trees to link with this branch:"
+	}
+	if m.sortedBy != "" {
+		s += mutedStyle.Render(" (sorted by " + m.sortedBy + ")")
+	}
+	s += "\n"
+	if m.filtering || m.filter != "" {
+		s += "/" + m.filter
+		if m.filtering {
+			s += "█"
+		}
+		s += "\n"
+	}
+	s += "\n"
+
+	// Only show the page around the cursor
+	start := 0
+	pageSize := m.pageSize()
+	if m.cursor >= pageSize {
+		start = m.cursor - pageSize + 1
+	}
+	end := start + pageSize
+	if end > len(m.filtered) {
+		end = len(m.filtered)
+	}
+	if start > 0 {
+		s += mutedStyle.Render(fmt.Sprintf("  ↑ %d more", start)) + "\n"
 	}
 
 	// Iterate over our choices
-	for i, choice := range m.choices {
+	for i := start; i < end; i++ {
+		index := m.filtered[i]
+		choice := m.choices[index]
 
 		// Is the cursor pointing at this choice?
 		cursor := " "
@@ -111,7 +304,7 @@ func (m selectionModel) View() string {
 
 		// Is this choice selected?
 		checked := "□" // not selected
-		if _, ok := m.selected[i]; ok {
+		if _, ok := m.selected[index]; ok {
 			checked = "■" // selected!
 		}
 
@@ -122,11 +315,30 @@ func (m selectionModel) View() string {
 			s += fmt.Sprintf("%s %s\n", cursor, choice)
 		}
 	}
+	if end < len(m.filtered) {
+		s += mutedStyle.Render(fmt.Sprintf("  ↓ %d more", len(m.filtered)-end)) + "\n"
+	}
+	if len(m.filtered) == 0 {
+		s += mutedStyle.Render("  no matching trees") + "\n"
+	}
+
+	// Preview the tree under the cursor
+	if current := m.current(); current != "" {
+		s += "\n"
+		states := m.previews[current]
+		if len(states) == 0 {
+			s += mutedStyle.Render("  no repositories in "+current) + "\n"
+		}
+		for _, state := range states {
+			s += mutedStyle.Render("  ⊢ "+state.Repo+" ("+state.Branch+")") + "\n"
+		}
+	}
+
 	if m.multiSelect {
-		s += fmt.Sprint("\n\033[31m", "space = Select   enter = Save   q = Quit", "\n\033[0m")
+		s += "\n" + helpStyle.Render("space = Select   / = Filter   enter = Save   q = Quit") + "\n"
 	} else {
 
-		s += fmt.Sprint("\n\033[31m", "enter = Select    q = Quit", "\n\033[0m")
+		s += "\n" + helpStyle.Render("enter = Select   / = Filter   q = Quit") + "\n"
 	}
 	return s
 }
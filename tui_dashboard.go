@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type statusMsg struct {
+	tree     string
+	statuses map[string]repoStatus
+}
+
+type actionDoneMsg struct {
+	message string
+}
+
+type prDoneMsg struct {
+	index int
+	err   error
+}
+
+func dashboardInitialModel(cfg Configuration) dashboardModel {
+	m := dashboardModel{
+		config:   cfg,
+		trees:    sortedTreeNames(cfg),
+		statuses: map[string]repoStatus{},
+	}
+	// start on the active tree
+	if i := getIndex(m.trees, cfg.ActiveTree); i != -1 {
+		m.treeCursor = i
+	}
+	return m
+}
+
+func (m dashboardModel) selectedTree() string {
+	if len(m.trees) == 0 {
+		return ""
+	}
+	return m.trees[m.treeCursor]
+}
+
+func (m dashboardModel) selectedStates() []State {
+	return m.config.Trees[m.selectedTree()].States
+}
+
+// refresh branch, dirty and ahead/behind for every repository of a tree
+func refreshStatusCmd(cfg Configuration, treeName string) tea.Cmd {
+	return func() tea.Msg {
+		states := cfg.Trees[treeName].States
+		results := make([]repoStatus, len(states))
+		runAcrossStates(states, 8, false, func(ctx context.Context, i int, state State) error {
+			repo, ok := cfg.Repositories[state.Repo]
+			if !ok {
+				results[i] = repoStatus{err: fmt.Errorf("not registered")}
+				return nil
+			}
+			results[i] = getRepoStatus(repo.Local, state.Branch)
+			return nil
+		})
+		statuses := map[string]repoStatus{}
+		for i, state := range states {
+			statuses[state.Repo] = results[i]
+		}
+		return statusMsg{tree: treeName, statuses: statuses}
+	}
+}
+
+func loadTreeCmd(cfg Configuration, treeName string) tea.Cmd {
+	return func() tea.Msg {
+		states := cfg.Trees[treeName].States
+		errs, err := runInDependencyOrder(cfg, states, 4, false, func(ctx context.Context, i int, state State) error {
+			if result := loadState(cfg, state); result.Error != "" {
+				return fmt.Errorf("%s", result.Error)
+			}
+			return nil
+		})
+		if err != nil {
+			return actionDoneMsg{message: err.Error()}
+		}
+		return actionDoneMsg{message: summarizeErrors("Loaded tree "+treeName, states, errs)}
+	}
+}
+
+func syncTreeCmd(cfg Configuration, treeName string) tea.Cmd {
+	return func() tea.Msg {
+		states := cfg.Trees[treeName].States
+		errs, err := runInDependencyOrder(cfg, states, 4, false, func(ctx context.Context, i int, state State) error {
+			result := fetchAndFastForward(cfg, state)
+			if result.diverged {
+				return fmt.Errorf("diverged, skipped")
+			}
+			return result.err
+		})
+		if err != nil {
+			return actionDoneMsg{message: err.Error()}
+		}
+		return actionDoneMsg{message: summarizeErrors("Synced tree "+treeName, states, errs)}
+	}
+}
+
+func summarizeErrors(done string, states []State, errs []error) string {
+	failures := []string{}
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, states[i].Repo+": "+err.Error())
+		}
+	}
+	if len(failures) == 0 {
+		return done + " ✅"
+	}
+	return done + " with errors ❌ " + strings.Join(failures, ", ")
+}
+
+// open pull requests one repository at a time, suspending the dashboard
+func openPullRequestCmd(cfg Configuration, states []State, index int, base string) tea.Cmd {
+	if index >= len(states) {
+		return nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return func() tea.Msg { return prDoneMsg{index: index, err: err} }
+	}
+	cmd := exec.Command(executable, "pr", base)
+	cmd.Dir = cfg.Repositories[states[index].Repo].Local
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return prDoneMsg{index: index, err: err}
+	})
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	if m.selectedTree() == "" {
+		return nil
+	}
+	return refreshStatusCmd(m.config, m.selectedTree())
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case statusMsg:
+		if msg.tree == m.selectedTree() {
+			m.statuses = msg.statuses
+		}
+	case actionDoneMsg:
+		m.busy = false
+		m.message = msg.message
+		return m, refreshStatusCmd(m.config, m.selectedTree())
+	case prDoneMsg:
+		states := m.selectedStates()
+		if msg.err != nil {
+			m.prFailures = append(m.prFailures, states[msg.index].Repo)
+		}
+		if msg.index+1 < len(states) {
+			return m, openPullRequestCmd(m.config, states, msg.index+1, m.input)
+		}
+		m.busy = false
+		m.message = "Opened pull requests into " + m.input + " ✅"
+		if len(m.prFailures) > 0 {
+			m.message = "Pull requests failed for " + strings.Join(m.prFailures, ", ") + " ❌"
+		}
+		m.input = ""
+		return m, refreshStatusCmd(m.config, m.selectedTree())
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case "confirm-delete":
+			return m.updateConfirmDelete(msg)
+		case "add-repo":
+			return m.updateAddRepo(msg)
+		case "add-branch", "pr-base":
+			return m.updateInput(msg)
+		}
+		return m.updateBrowse(msg)
+	}
+	return m, nil
+}
+
+func (m dashboardModel) updateBrowse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "tab", "left", "right":
+		m.repoFocus = !m.repoFocus
+		return m, nil
+	case "up", "k":
+		if m.repoFocus {
+			if m.repoCursor > 0 {
+				m.repoCursor--
+			}
+		} else if m.treeCursor > 0 {
+			m.treeCursor--
+			return m.treeChanged()
+		}
+		return m, nil
+	case "down", "j":
+		if m.repoFocus {
+			if m.repoCursor < len(m.selectedStates())-1 {
+				m.repoCursor++
+			}
+		} else if m.treeCursor < len(m.trees)-1 {
+			m.treeCursor++
+			return m.treeChanged()
+		}
+		return m, nil
+	}
+
+	// actions below need a tree and no running action
+	if m.busy || m.selectedTree() == "" {
+		return m, nil
+	}
+	treeName := m.selectedTree()
+	switch msg.String() {
+	case "r":
+		return m, refreshStatusCmd(m.config, treeName)
+	case "l":
+		m.busy = true
+		m.message = "Loading tree " + treeName + " 🪵"
+		m.config.ActiveTree = treeName
+		touchTree(m.config, treeName)
+		saveConfigToml(m.config)
+		return m, loadTreeCmd(m.config, treeName)
+	case "s":
+		m.busy = true
+		m.message = "Syncing tree " + treeName + " 🪵"
+		return m, syncTreeCmd(m.config, treeName)
+	case "p":
+		m.mode = "pr-base"
+		m.input = ""
+	case "a":
+		m.mode = "add-repo"
+		m.repoChoices = sortedRepositoryNames(m.config)
+		m.choiceCursor = 0
+	case "x":
+		states := m.selectedStates()
+		if !m.repoFocus || len(states) == 0 {
+			return m, nil
+		}
+		tree := m.config.Trees[treeName]
+		removed := tree.States[m.repoCursor]
+		tree.States = append(states[:m.repoCursor:m.repoCursor], states[m.repoCursor+1:]...)
+		m.config.Trees[treeName] = tree
+		saveConfigToml(m.config)
+		if m.repoCursor > 0 && m.repoCursor >= len(tree.States) {
+			m.repoCursor--
+		}
+		m.message = "Removed " + removed.Repo + " (" + removed.Branch + ") from " + treeName
+	case "d":
+		m.mode = "confirm-delete"
+	}
+	return m, nil
+}
+
+func (m dashboardModel) treeChanged() (tea.Model, tea.Cmd) {
+	m.repoCursor = 0
+	m.statuses = map[string]repoStatus{}
+	return m, refreshStatusCmd(m.config, m.selectedTree())
+}
+
+func (m dashboardModel) updateConfirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.mode = ""
+	if msg.String() != "y" {
+		m.message = ""
+		return m, nil
+	}
+	treeName := m.selectedTree()
+	delete(m.config.Trees, treeName)
+	if m.config.ActiveTree == treeName {
+		m.config.ActiveTree = ""
+	}
+	saveConfigToml(m.config)
+	m.trees = sortedTreeNames(m.config)
+	if m.treeCursor >= len(m.trees) && m.treeCursor > 0 {
+		m.treeCursor--
+	}
+	m.message = "Deleted tree " + treeName
+	return m.treeChanged()
+}
+
+func (m dashboardModel) updateAddRepo(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.mode = ""
+	case "up", "k":
+		if m.choiceCursor > 0 {
+			m.choiceCursor--
+		}
+	case "down", "j":
+		if m.choiceCursor < len(m.repoChoices)-1 {
+			m.choiceCursor++
+		}
+	case "enter":
+		if len(m.repoChoices) == 0 {
+			m.mode = ""
+			return m, nil
+		}
+		m.addRepo = m.repoChoices[m.choiceCursor]
+		m.mode = "add-branch"
+		// suggest the branch currently checked out
+		m.input, _ = getBranchNameIn(m.config.Repositories[m.addRepo].Local)
+	}
+	return m, nil
+}
+
+func (m dashboardModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = ""
+		m.input = ""
+		return m, nil
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			runes := []rune(m.input)
+			m.input = string(runes[:len(runes)-1])
+		}
+		return m, nil
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+		return m, nil
+	case tea.KeyEnter:
+	default:
+		return m, nil
+	}
+
+	mode := m.mode
+	m.mode = ""
+	if m.input == "" {
+		return m, nil
+	}
+	treeName := m.selectedTree()
+	if mode == "pr-base" {
+		m.busy = true
+		m.prFailures = nil
+		return m, openPullRequestCmd(m.config, m.selectedStates(), 0, m.input)
+	}
+
+	// add or replace the state for the repository
+	tree := m.config.Trees[treeName]
+	newState := State{Repo: m.addRepo, Branch: m.input}
+	if i := getStateIndex(tree.States, m.addRepo); i != -1 {
+		tree.States[i] = newState
+	} else {
+		tree.States = append(tree.States, newState)
+	}
+	m.config.Trees[treeName] = tree
+	saveConfigToml(m.config)
+	m.message = "Added " + m.addRepo + " (" + m.input + ") to " + treeName
+	m.input = ""
+	return m, refreshStatusCmd(m.config, treeName)
+}
+
+func (m dashboardModel) View() string {
+	// trees pane
+	trees := lipgloss.NewStyle().Bold(true).Render("Trees") + "\n\n"
+	if len(m.trees) == 0 {
+		trees += mutedStyle.Render("no trees yet")
+	}
+	for i, treeName := range m.trees {
+		cursor := "  "
+		if i == m.treeCursor {
+			cursor = "→ "
+		}
+		active := ""
+		if treeName == m.config.ActiveTree {
+			active = " 🌳"
+		}
+		trees += cursor + treeName + active + "\n"
+	}
+
+	// repositories pane
+	repos := ""
+	if treeName := m.selectedTree(); treeName != "" {
+		tree := m.config.Trees[treeName]
+		repos += lipgloss.NewStyle().Bold(true).Render(treeName) + " " + mutedStyle.Render(tree.Owner) + "\n\n"
+		if len(tree.States) == 0 {
+			repos += mutedStyle.Render("no repositories in tree")
+		}
+		for i, state := range tree.States {
+			cursor := "  "
+			if m.repoFocus && i == m.repoCursor {
+				cursor = "→ "
+			}
+			repos += fmt.Sprintf("%s%-30s %-30s %s\n", cursor, state.Repo, state.Branch, formatRepoStatus(state, m.statuses))
+		}
+	}
+
+	leftPane, rightPane := paneStyle, focusedPaneStyle
+	if !m.repoFocus {
+		leftPane, rightPane = focusedPaneStyle, paneStyle
+	}
+	leftWidth := 28
+	rightWidth := 90
+	if m.width > leftWidth+10 {
+		rightWidth = m.width - leftWidth - 8
+	}
+	s := lipgloss.JoinHorizontal(lipgloss.Top,
+		leftPane.Copy().Width(leftWidth).Render(trees),
+		rightPane.Copy().Width(rightWidth).Render(repos),
+	) + "\n"
+
+	// prompt, message and keybindings
+	switch m.mode {
+	case "confirm-delete":
+		s += "Delete tree " + m.selectedTree() + "? [y/N]\n"
+	case "pr-base":
+		s += "Open pull requests into branch: " + m.input + "█\n"
+	case "add-branch":
+		s += "Branch for " + m.addRepo + ": " + m.input + "█\n"
+	case "add-repo":
+		s += "Add repository to " + m.selectedTree() + ":\n"
+		for i, repoName := range m.repoChoices {
+			cursor := "  "
+			if i == m.choiceCursor {
+				cursor = "→ "
+			}
+			s += cursor + repoName + "\n"
+		}
+	default:
+		s += m.message + "\n"
+	}
+	s += helpStyle.Render("tab = Switch pane   l = Load   s = Sync   p = Open PRs   a = Add repo   x = Remove repo   d = Delete tree   r = Refresh   q = Quit")
+	return s
+}
+
+func formatRepoStatus(state State, statuses map[string]repoStatus) string {
+	status, ok := statuses[state.Repo]
+	if !ok {
+		return mutedStyle.Render("…")
+	}
+	if status.err != nil {
+		return "❌ " + status.err.Error()
+	}
+	s := "✓"
+	if status.branch != state.Branch {
+		s = "≠ " + status.branch
+	}
+	if status.dirty {
+		s += " *dirty"
+	}
+	if status.upstream {
+		if status.ahead > 0 {
+			s += fmt.Sprintf(" ↑%d", status.ahead)
+		}
+		if status.behind > 0 {
+			s += fmt.Sprintf(" ↓%d", status.behind)
+		}
+	} else {
+		s += mutedStyle.Render(" no upstream")
+	}
+	return s
+}
@@ -4,17 +4,62 @@ type Configuration struct {
 	Trees        map[string]Tree       `toml:"trees"`
 	Repositories map[string]Repository `toml:"repositories"`
 	ActiveTree   string                `toml:"active_tree"`
+	PickerSort   string                `toml:"picker_sort,omitempty"`
+	TitleFormat  string                `toml:"title_format,omitempty"`
+	TitleRules   []TitleRule           `toml:"title_rules,omitempty"`
+	BranchPolicy BranchPolicy          `toml:"branch_policy,omitempty"`
+	GitBackend   string                `toml:"git_backend,omitempty"`
+}
+
+// naming conventions for branches
+type BranchPolicy struct {
+	Template string   `toml:"template,omitempty" json:"template,omitempty"`
+	Types    []string `toml:"types,omitempty" json:"types,omitempty"`
+	Patterns []string `toml:"patterns,omitempty" json:"patterns,omitempty"`
+	Exempt   []string `toml:"exempt,omitempty" json:"exempt,omitempty"`
+	Enforce  string   `toml:"enforce,omitempty" json:"enforce,omitempty"` // warn (default) or refuse
+}
+
+// rewrite of the branch name when deriving pull request titles
+type TitleRule struct {
+	Pattern string `toml:"pattern" json:"pattern"`
+	Replace string `toml:"replace" json:"replace"`
+	Case    string `toml:"case,omitempty" json:"case,omitempty"`
 }
 
 type Tree struct {
-	Name   string  `toml:"name"`
-	Owner  string  `toml:"owner"`
-	States []State `toml:"states"`
+	Name       string   `toml:"name" json:"name"`
+	Owner      string   `toml:"owner" json:"owner"`
+	States     []State  `toml:"states" json:"states"`
+	LastUsed   int64    `toml:"last_used,omitempty" json:"last_used,omitempty"`
+	MergeOrder []string `toml:"merge_order,omitempty" json:"merge_order,omitempty"`
 }
 
 type Repository struct {
-	Remote string `toml:"remote"`
-	Local  string `toml:"local"`
+	Remote        string              `toml:"remote" json:"remote"`
+	Local         string              `toml:"local" json:"local"`
+	DependsOn     []string            `toml:"depends_on,omitempty" json:"depends_on,omitempty"`
+	PullRequests  PullRequestDefaults `toml:"pull_requests,omitempty" json:"pull_requests,omitempty"`
+	DefaultBranch string              `toml:"default_branch,omitempty" json:"default_branch,omitempty"`
+}
+
+// defaults applied to every pull request opened in a repository
+type PullRequestDefaults struct {
+	Draft               bool     `toml:"draft,omitempty" json:"draft,omitempty"`
+	Reviewers           []string `toml:"reviewers,omitempty" json:"reviewers,omitempty"`
+	Labels              []string `toml:"labels,omitempty" json:"labels,omitempty"`
+	Assignees           []string `toml:"assignees,omitempty" json:"assignees,omitempty"`
+	Milestone           string   `toml:"milestone,omitempty" json:"milestone,omitempty"`
+	CodeOwnersReviewers bool     `toml:"codeowners_reviewers,omitempty" json:"codeowners_reviewers,omitempty"`
+	BodyTemplate        string   `toml:"body_template,omitempty" json:"body_template,omitempty"`
+}
+
+type pullRequestOptions struct {
+	draft     bool
+	reviewers []string
+	labels    []string
+	assignees []string
+	milestone string
 }
 
 type selectionModel struct {
@@ -23,9 +68,43 @@ type selectionModel struct {
 	selected    map[int]struct{}
 	multiSelect bool
 	quit        bool
+	sortedBy    string
+	previews    map[string][]State
+	filter      string
+	filtering   bool
+	filtered    []int
+	height      int
 }
 
 type State struct {
-	Repo   string `toml:"repo"`
-	Branch string `toml:"branch"`
+	Repo   string `toml:"repo" json:"repo"`
+	Branch string `toml:"branch" json:"branch"`
+}
+
+type repoStatus struct {
+	branch   string
+	dirty    bool
+	ahead    int
+	behind   int
+	upstream bool
+	err      error
+}
+
+type dashboardModel struct {
+	config       Configuration
+	trees        []string
+	treeCursor   int
+	repoCursor   int
+	repoFocus    bool
+	statuses     map[string]repoStatus
+	mode         string
+	repoChoices  []string
+	choiceCursor int
+	addRepo      string
+	input        string
+	message      string
+	busy         bool
+	prFailures   []string
+	width        int
+	height       int
 }
@@ -1,13 +1,20 @@
 package main
 
 import (
-	"crypto/sha256"
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	toml "github.com/pelletier/go-toml/v2"
 	"github.com/urfave/cli/v2"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 	"sort"
 )
@@ -47,26 +54,281 @@ func loadConfigToml() Configuration {
 
 // get working directory of repository
 func getLocalRepository() string {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	out, err := cmd.Output()
+	currentRepository, err := gitBackend.TopLevel("")
 	if err != nil {
 		panic(err)
 	}
-	currentRepository := strings.TrimSpace(string(out))
 	return currentRepository
 }
 
 // get remote repository url
 func getRemoteRepository() string {
-	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
-	out, err := cmd.Output()
+	repositoryUrl, err := gitBackend.RemoteURL("")
 	if err != nil {
 		panic(err)
 	}
-	repositoryUrl := strings.TrimSpace(string(out))
 	return repositoryUrl
 }
 
+// set on git processes started by bsync, so our hooks can ignore them
+const internalGitEnv = "BSYNC_INTERNAL"
+
+// run git in a directory and return trimmed output
+func runGitIn(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), internalGitEnv+"=1")
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// run a command in a directory and return trimmed output
+func runCommandIn(dir string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// get remote repository url of a repository directory
+func getRemoteRepositoryIn(dir string) (string, error) {
+	return gitBackend.RemoteURL(dir)
+}
+
+// check that dir is the top level of a git repository
+func isGitRepository(dir string) bool {
+	topLevel, err := gitBackend.TopLevel(dir)
+	if err != nil {
+		return false
+	}
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	resolvedTopLevel, err := filepath.EvalSymlinks(topLevel)
+	if err != nil {
+		return false
+	}
+	return filepath.Clean(resolvedDir) == filepath.Clean(resolvedTopLevel)
+}
+
+// check if branch exists locally or on origin
+func branchExistsIn(dir string, branch string) (local bool, remote bool) {
+	return gitBackend.BranchExists(dir, branch)
+}
+
+// remote branch when it exists, it's what pull requests compare against,
+// else the local branch
+func baseRefIn(dir string, base string) string {
+	if _, remote := branchExistsIn(dir, base); remote {
+		return "origin/" + base
+	}
+	return base
+}
+
+// branch origin/HEAD points to, else main or master
+func defaultBranchIn(dir string) string {
+	if branch, err := gitBackend.DefaultBranch(dir); err == nil {
+		return branch
+	}
+	for _, branch := range []string{"main", "master"} {
+		if local, remote := branchExistsIn(dir, branch); local || remote {
+			return branch
+		}
+	}
+	return "main"
+}
+
+// recorded default branch of a registered repository, else the detected one
+func repositoryDefaultBranch(cfg Configuration, repoName string) string {
+	repo := cfg.Repositories[repoName]
+	if repo.DefaultBranch != "" {
+		return repo.DefaultBranch
+	}
+	return defaultBranchIn(repo.Local)
+}
+
+// reduce a remote url to host/path so ssh and https remotes compare equal
+func normalizeRemote(remote string) string {
+	remote = strings.TrimSpace(remote)
+	remote = strings.TrimSuffix(remote, "/")
+	remote = strings.TrimSuffix(remote, ".git")
+	for _, scheme := range []string{"https://", "http://", "ssh://", "git://"} {
+		remote = strings.TrimPrefix(remote, scheme)
+	}
+	if at := strings.Index(remote, "@"); at != -1 {
+		remote = remote[at+1:]
+	}
+	// scp-like syntax (host:owner/repo)
+	if colon := strings.Index(remote, ":"); colon != -1 && !strings.Contains(remote[:colon], "/") {
+		remote = remote[:colon] + "/" + remote[colon+1:]
+	}
+	return strings.ToLower(remote)
+}
+
+func sameRemote(a string, b string) bool {
+	return normalizeRemote(a) == normalizeRemote(b)
+}
+
+// get positional arguments, applying any command flags given after them
+// (urfave/cli stops parsing flags at the first positional argument)
+func positionalArgs(cCtx *cli.Context) ([]string, error) {
+	args := []string{}
+	rawArgs := cCtx.Args().Slice()
+	for i := 0; i < len(rawArgs); i++ {
+		arg := rawArgs[i]
+		if arg == "--" {
+			return append(args, rawArgs[i+1:]...), nil
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			args = append(args, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		flag := findFlag(cCtx.Command.Flags, name)
+		if flag == nil {
+			return nil, fmt.Errorf("flag provided but not defined: %s", arg)
+		}
+		if _, isBool := flag.(*cli.BoolFlag); isBool && !hasValue {
+			value = "true"
+		} else if !hasValue {
+			if i+1 >= len(rawArgs) {
+				return nil, fmt.Errorf("flag needs an argument: %s", arg)
+			}
+			i++
+			value = rawArgs[i]
+		}
+		if err := cCtx.Set(name, value); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func findFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		for _, flagName := range flag.Names() {
+			if flagName == name {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+// shared so that consecutive prompts don't lose buffered input
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// ask a yes/no question on the terminal
+func confirm(question string) bool {
+	fmt.Fprint(progressWriter(), question+" [y/N]: ")
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// ask for a line of text, returning fallback when the answer is empty
+func ask(question string, fallback string) string {
+	if fallback != "" {
+		question += " (default: " + fallback + ")"
+	}
+	fmt.Fprint(progressWriter(), question+": ")
+	answer, _ := stdinReader.ReadString('\n')
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return fallback
+}
+
+// tree names in alphabetical order
+func sortedTreeNames(cfg Configuration) []string {
+	names := []string{}
+	for name := range cfg.Trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// record that a tree was just used
+func touchTree(cfg Configuration, name string) {
+	tree := cfg.Trees[name]
+	tree.LastUsed = time.Now().Unix()
+	cfg.Trees[name] = tree
+}
+
+var treeSortOrders = []string{"alphabetical", "recent", "owner"}
+
+// error for anything but a known sort order, "" means alphabetical
+func checkTreeSortOrder(by string) error {
+	if by != "" && getIndex(treeSortOrders, by) == -1 {
+		return fmt.Errorf("unknown sort order %q, expected one of: %s", by, strings.Join(treeSortOrders, ", "))
+	}
+	return nil
+}
+
+// tree names sorted alphabetically, by most recently used or by owner
+func sortTreeNames(cfg Configuration, by string) []string {
+	names := sortedTreeNames(cfg)
+	switch by {
+	case "recent":
+		sort.SliceStable(names, func(i, j int) bool {
+			return cfg.Trees[names[i]].LastUsed > cfg.Trees[names[j]].LastUsed
+		})
+	case "owner":
+		sort.SliceStable(names, func(i, j int) bool {
+			return cfg.Trees[names[i]].Owner < cfg.Trees[names[j]].Owner
+		})
+	}
+	return names
+}
+
+// score how well pattern matches s as a case-insensitive subsequence,
+// favouring consecutive and early matches
+func fuzzyScore(pattern string, s string) (int, bool) {
+	pattern = strings.ToLower(pattern)
+	s = strings.ToLower(s)
+	if pattern == "" {
+		return 0, true
+	}
+	score := 0
+	patternRunes := []rune(pattern)
+	p := 0
+	previous := -2
+	for i, r := range []rune(s) {
+		if p == len(patternRunes) {
+			break
+		}
+		if r != patternRunes[p] {
+			continue
+		}
+		if i == previous+1 {
+			score += 5
+		}
+		if i == 0 {
+			score += 3
+		}
+		score++
+		previous = i
+		p++
+	}
+	if p < len(patternRunes) {
+		return 0, false
+	}
+	return score, true
+}
+
+// repository names in alphabetical order
+func sortedRepositoryNames(cfg Configuration) []string {
+	names := []string{}
+	for name := range cfg.Repositories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func parseRepositoryName(repositoryUrl string) string {
 
 	if strings.HasSuffix(repositoryUrl, ".git") {
@@ -79,47 +341,62 @@ func parseRepositoryName(repositoryUrl string) string {
 }
 
 func getBranchName() string {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	out, err := cmd.Output()
+	currentBranch, err := gitBackend.CurrentBranch("")
 	if err != nil {
 		panic(err)
 	}
-	currentBranch := strings.TrimSpace(string(out))
 	return currentBranch
 }
 
-func loadProject(project string, cCtx *cli.Context, cfg Configuration) {
-	projectDetails := cfg.Trees[project]
-	// fmt.Println("trees:", cfg.Trees, "project:", project)
-	projectStates := projectDetails.States
-	for _, state := range projectStates {
-		fmt.Printf("\npulling branch %s 🪵\n", state.Branch)
-		cmd := exec.Command("git", "checkout", state.Branch)
-		cmd.Dir = cfg.Repositories[state.Repo].Local
-		fmt.Printf("\033[F\033[2K") // move cursor up and clear line
-		_, err := cmd.Output()
-		if err != nil {
-			fmt.Printf("Error checking out branch %s (%s) ❌", state.Branch, state.Repo)
-			fmt.Println(err.Error())
-		} else {
-			fmt.Printf("Checked out branch %s (%s) ✅\n", state.Branch, state.Repo)
-			fmt.Printf("Pulling branch %s (%s) 🪵\n", state.Branch, state.Repo)
-			pullCmd := exec.Command("git", "pull", "--ff-only", "origin", state.Branch)
-			pullCmd.Dir = cfg.Repositories[state.Repo].Local
-			_, err :=pullCmd.Output()
-			if err != nil {
-				fmt.Printf(err.Error())
-				fmt.Printf("\033[F\033[2KError pulling branch %s (%s) ❌\n", state.Branch, state.Repo)
-			} else {
-				fmt.Printf("\033[F\033[2KPulled branch %s (%s) ✅\n", state.Branch, state.Repo)
-			}
+func loadProject(project string, cCtx *cli.Context, cfg Configuration) ([]stateResultJSON, error) {
+	projectStates := cfg.Trees[project].States
+	results := make([]stateResultJSON, len(projectStates))
+	var mu sync.Mutex
+	_, err := runInDependencyOrder(cfg, projectStates, cCtx.Int("parallel"), false, func(ctx context.Context, i int, state State) error {
+		results[i] = loadState(cfg, state)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case results[i].Result == "pulled":
+			progressf("Pulled branch %s (%s) %s\n", state.Branch, state.Repo, icon("ok"))
+		case results[i].Result == "checked out":
+			progressf("Error pulling branch %s (%s) %s %s\n", state.Branch, state.Repo, icon("fail"), results[i].Error)
+		default:
+			progressf("Error checking out branch %s (%s) %s %s\n", state.Branch, state.Repo, icon("fail"), results[i].Error)
 		}
-		fmt.Println("")
+		return nil
+	})
+	return results, err
+}
+
+// check out and pull the branch of a state
+func loadState(cfg Configuration, state State) stateResultJSON {
+	dir := cfg.Repositories[state.Repo].Local
+	if err := checkoutBranchIn(dir, state.Branch); err != nil {
+		return newStateResultJSON(state, "", false, fmt.Errorf("checkout failed: %s", gitErrorMessage(err)), 0, 0)
+	}
+	if err := pullBranchIn(dir, state.Branch); err != nil {
+		return newStateResultJSON(state, "checked out", false, fmt.Errorf("pull failed: %s", gitErrorMessage(err)), 0, 0)
 	}
-	// fmt.Println("\033[2KLoaded tree", project, "🌳")
+	return newStateResultJSON(state, "pulled", false, nil, 0, 0)
+}
+
+func checkoutBranchIn(dir string, branch string) error {
+	return gitBackend.Checkout(dir, branch)
+}
+
+func pullBranchIn(dir string, branch string) error {
+	return gitBackend.Pull(dir, branch)
 }
 
 func saveConfigToml(cfg Configuration) {
+	withConfigLock(func() {
+		writeConfigToml(cfg)
+	})
+}
+
+// write the config, callers hold the config lock
+func writeConfigToml(cfg Configuration) {
 	// define config location
 	homeDir, e := os.UserHomeDir()
 	if e != nil {
@@ -137,6 +414,25 @@ func saveConfigToml(cfg Configuration) {
 	os.WriteFile(fullConfigPath, b, 0644)
 }
 
+// run fn while holding an exclusive lock on the config, so concurrent
+// saves don't interleave
+func withConfigLock(fn func()) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.OpenFile(homeDir+"/Library/Application Support/bsync/config.lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		fn()
+		return
+	}
+	defer file.Close()
+	if err := lockFile(file); err == nil {
+		defer unlockFile(file)
+	}
+	fn()
+}
+
 func getIndex(choices []string, choice string) int {
 	for i, c := range choices {
 		if c == choice {
@@ -146,13 +442,26 @@ func getIndex(choices []string, choice string) int {
 	return -1
 }
 
+// index of the state for a repository, or -1
+func getStateIndex(states []State, repo string) int {
+	for i, state := range states {
+		if state.Repo == repo {
+			return i
+		}
+	}
+	return -1
+}
+
 func remove(s []State, i int) []State {
 	s[i] = s[len(s)-1]
 	return s[:len(s)-1]
 }
 
-func makeNewTreesFromSelection(m selectionModel, branch string, repositoryName string, config Configuration) map[string]Tree {
-	gitUser := getGitUser()
+func makeNewTreesFromSelection(m selectionModel, branch string, repositoryName string, config Configuration) (map[string]Tree, error) {
+	gitUser, err := getGitUser()
+	if err != nil {
+		return nil, err
+	}
 
 	selectedTreeNames := []string{}
 	for _, choice := range m.choices {
@@ -207,12 +516,9 @@ func makeNewTreesFromSelection(m selectionModel, branch string, repositoryName s
 		}
 
 		// update tree
-		newTree := Tree{
-			Name:   tree.Name,
-			Owner:  gitUser,
-			States: treeStates,
-		}
-		newTrees[tree.Name] = newTree
+		tree.Owner = gitUser
+		tree.States = treeStates
+		newTrees[treeName] = tree
 	}
 
 	for _, treeName := range unSelectedTreeNames {
@@ -237,173 +543,235 @@ func makeNewTreesFromSelection(m selectionModel, branch string, repositoryName s
 		}
 
 		// update tree
-		newTree := Tree{
-			Name:   tree.Name,
-			Owner:  gitUser,
-			States: treeStates,
-		}
-		newTrees[tree.Name] = newTree
+		tree.Owner = gitUser
+		tree.States = treeStates
+		newTrees[treeName] = tree
 	}
 
-	return newTrees
+	return newTrees, nil
 }
 
-func getGitUser() string {
-	cmd := exec.Command("git", "config", "--get", "user.email")
-	out, err := cmd.Output()
-	if err != nil {
-		panic(err)
+// owner recorded on trees, the configured git user email in upper case
+func getGitUser() (string, error) {
+	email, err := gitBackend.UserEmail("")
+	if err != nil || email == "" {
+		return "", fmt.Errorf("git user.email is not set")
 	}
-	user := strings.ToUpper(strings.TrimSpace(string(out)))
-	return user
+	return strings.ToUpper(email), nil
 }
 
-type PatternFormat struct {
-	Pattern     string
-	Replacement string
-	Placeholder string
+// create pull request with gh and return its url
+func openPullRequest(title string, destinationBranch string, body string, options pullRequestOptions) (string, error) {
+    args := []string{"pr", "create", "--title", title, "--body", body, "--base", destinationBranch}
+    if options.draft {
+        args = append(args, "--draft")
+    }
+    for _, reviewer := range options.reviewers {
+        args = append(args, "--reviewer", reviewer)
+    }
+    for _, label := range options.labels {
+        args = append(args, "--label", label)
+    }
+    for _, assignee := range options.assignees {
+        args = append(args, "--assignee", assignee)
+    }
+    if options.milestone != "" {
+        args = append(args, "--milestone", options.milestone)
+    }
+    fmt.Fprintln(progressWriter(), highlightStyle.Render("Creating pull request into branch "+destinationBranch+"...")+"\n"+titleStyle.Render(" - "+title))
+    out, err := runCommandIn("", "gh", args...)
+    if err != nil {
+        return "", fmt.Errorf("%s", gitErrorMessage(err))
+    }
+    // gh prints the url of the new pull request last
+    lines := strings.Split(out, "\n")
+    url := strings.TrimSpace(lines[len(lines)-1])
+    if !strings.HasPrefix(url, "http") {
+        return "", fmt.Errorf("unexpected output from gh: %s", out)
+    }
+    return url, nil
 }
 
-func formatPRTitle(currentBranch string, destinationBranch string) string {
-	branchNameTitle := currentBranch
-
-	rawPatterns := []PatternFormat{
-		{
-			Pattern:     "--",
-			Replacement: " -- ",
-			Placeholder: "",
-		},
-		{
-			Pattern:     "-",
-			Replacement: " ",
-			Placeholder: "",
-		},
+// local branches of the current repository, most recently committed first
+func listBranches() error {
+	details, err := gitBackend.BranchDetails("")
+	if err != nil {
+		return err
 	}
-
-	patterns := []PatternFormat{}
-
-	// replace all patterns with placeholders
-	for _, pattern := range rawPatterns {
-		pattern.Placeholder = fmt.Sprintf("{%x}", sha256.Sum256([]byte(pattern.Pattern)))
-		patterns = append(patterns, pattern)
-		branchNameTitle = strings.ReplaceAll(branchNameTitle, pattern.Pattern, pattern.Placeholder)
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].Committed > details[j].Committed
+	})
+	for _, detail := range details {
+		fmt.Println(detail.Name)
 	}
+	return nil
+}
 
-	// replace all placeholders with replacements
-	for _, pattern := range patterns {
-		branchNameTitle = strings.ReplaceAll(branchNameTitle, pattern.Placeholder, pattern.Replacement)
+// run fn for every state with at most parallel running at once; when failFast
+// is set the context passed to fn is cancelled after the first error
+func runAcrossStates(states []State, parallel int, failFast bool, fn func(ctx context.Context, i int, state State) error) []error {
+	if parallel < 1 {
+		parallel = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make([]error, len(states))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, state := range states {
+		slots <- struct{}{}
+		if ctx.Err() != nil {
+			<-slots
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, state State) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			errs[i] = fn(ctx, i, state)
+			if errs[i] != nil && failFast {
+				cancel()
+			}
+		}(i, state)
 	}
+	wg.Wait()
+	return errs
+}
 
-	prefix := "[" + destinationBranch + "]"
-	return prefix + " " + branchNameTitle
+// writer that prefixes every complete line before passing it on
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	mu     *sync.Mutex
+	buf    []byte
 }
 
-func openPullRequest(title string, destinationBranch string, body string) {
-    cmd := exec.Command("gh", "pr", "create", "--title", title, "--body", body, "--base", destinationBranch)
-    cmd.Stdout = os.Stdout
-    cmd.Stderr = os.Stderr
-    fmt.Println("\u001b[31mCreating pull request into branch " + destinationBranch + "...\u001b[0m" + "\n\u001b[34m - " + title + "\u001b")
-    cmd.Run()
-    fmt.Println()
-    return
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf[:i])
+		w.mu.Unlock()
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
 }
 
-type BranchTime struct {
-	Branch string
-	Time   time.Time
+// write any trailing partial line
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.mu.Lock()
+		fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+		w.mu.Unlock()
+		w.buf = nil
+	}
 }
 
-func listBranches() {
-	// grep a list of all branches in local git repo
-	// cmd := exec.Command("git", "branch", "--list")
-	// out, err := cmd.Output()
-	// if err != nil {
-	// 	panic(err)
-	// }
+// get checked out branch of a repository directory
+func getBranchNameIn(dir string) (string, error) {
+	return gitBackend.CurrentBranch(dir)
+}
 
-	// output := string(out)
+// check for uncommitted changes to tracked files
+func isDirtyIn(dir string) (bool, error) {
+	return gitBackend.IsDirty(dir)
+}
 
-	// // remove asterisk from output
-	// output = strings.ReplaceAll(output, "*", "")
+// get upstream of a branch, e.g. origin/feature
+func getUpstreamIn(dir string, branch string) (string, error) {
+	return gitBackend.Upstream(dir, branch)
+}
 
-	// // split branches into array
-	// branches := strings.Split(string(out), "\n")
+// count commits only on local and only on other
+func aheadBehindIn(dir string, local string, other string) (ahead int, behind int, err error) {
+	return gitBackend.AheadBehind(dir, local, other)
+}
 
-	// for i, branch := range branches {
-	// 	branches[i] = strings.TrimSpace(branch)
-	// 	fmt.Println(i, branch)
-	// }
-allBranches := []string{}
-branchesWithTime := []BranchTime{}
-subDirs := []string{}
-	// list files in .git/refs/heads
-	items, err := os.ReadDir(".git/refs/heads")
+// checked out branch, dirty state and ahead/behind of branch against its upstream
+func getRepoStatus(dir string, branch string) repoStatus {
+	status := repoStatus{}
+	currentBranch, err := getBranchNameIn(dir)
 	if err != nil {
-		panic(err)
+		status.err = fmt.Errorf("not a git repository")
+		return status
 	}
-
-	for _, item := range items {
-		// open dir if it is and add to list
-		if item.IsDir() {
-			subDirs = append(subDirs, item.Name())
-		}
+	status.branch = currentBranch
+	status.dirty, _ = isDirtyIn(dir)
+	if upstream, err := getUpstreamIn(dir, branch); err == nil {
+		status.upstream = true
+		status.ahead, status.behind, _ = aheadBehindIn(dir, branch, upstream)
 	}
+	return status
+}
 
-	for _, subDir := range subDirs {
-		items, err := os.ReadDir(".git/refs/heads/" + subDir)
-		if err != nil {
-			panic(err)
-		}
-
-		for _, item := range items {
-			allBranches = append(allBranches, subDir + "/" + item.Name())
+// extract the last line git wrote to stderr, ignoring hints
+func gitErrorMessage(err error) string {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		lines := strings.Split(strings.TrimSpace(string(exitErr.Stderr)), "\n")
+		// skip trailing hints, they don't say what went wrong
+		for i := len(lines) - 1; i >= 0; i-- {
+			if line := strings.TrimSpace(lines[i]); line != "" && !strings.HasPrefix(line, "hint:") {
+				return line
+			}
 		}
 	}
+	return err.Error()
+}
 
+// print rows as aligned columns
+func printTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
 
+// names of local branches in the current repository
+func localBranchNames() []string {
+	branches, _ := gitBackend.ListBranches("")
+	return branches
+}
 
-	for _, branch := range allBranches {
-		// sort branches by last commit date
-		cmd := exec.Command("git", "log", "-1", "--format=%cd", branch)
-		out, err := cmd.Output()
-		if err != nil {
-			panic(err)
-		}
-
-		// convert date to unix timestamp
-		date, err := time.Parse("Mon Jan 2 15:04:05 2006 -0700", string(out))
-		if err != nil {
-			panic(err)
+// append values not already in list
+func appendUnique(list []string, values ...string) []string {
+	result := append([]string{}, list...)
+	for _, value := range values {
+		if value != "" && getIndex(result, value) == -1 {
+			result = append(result, value)
 		}
-
-		fmt.Println(branch, date)
-
-		// add branch and time to array
-		branchesWithTime = append(branchesWithTime, BranchTime{
-			Branch: branch,
-			Time:   date,
-		})
 	}
+	return result
+}
 
-	fmt.Println(branchesWithTime)
-
-	// sort branches by time
-	sort.Slice(branchesWithTime, func(i, j int) bool {
-		return branchesWithTime[i].Time.After(branchesWithTime[j].Time)
-	})
-
-	// print branches
-	for _, branch := range branchesWithTime {
-		fmt.Println(branch.Branch)
+func openInBrowser(url string) error {
+	name := "xdg-open"
+	switch runtime.GOOS {
+	case "darwin":
+		name = "open"
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
 	}
+	return exec.Command(name, url).Start()
+}
 
-
-
-
-
-
-	// for _, file := range files {
-	// 	fmt.Println(file.Name())
-	// }
-
-}
\ No newline at end of file
+// copy text with the platform clipboard tool
+func copyToClipboard(text string) error {
+	candidates := [][]string{{"pbcopy"}, {"wl-copy"}, {"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}, {"clip"}}
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(candidate[0], candidate[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return fmt.Errorf("no clipboard tool found")
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestGetGitUser(t *testing.T) {
+	fake := useFakeGit(t)
+	fake.addRepository("", &fakeRepository{UserEmail: "dev@example.com"})
+
+	user, err := getGitUser()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if user != "DEV@EXAMPLE.COM" {
+		t.Errorf("expected DEV@EXAMPLE.COM, got %s", user)
+	}
+
+	fake.addRepository("", &fakeRepository{})
+	if _, err := getGitUser(); err == nil {
+		t.Errorf("expected an error without user.email")
+	}
+}